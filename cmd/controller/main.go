@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/jinghzhu/KubernetesCRD/pkg/config"
+	"github.com/jinghzhu/KubernetesCRD/pkg/controller"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+
+	jinghzhuclientset "github.com/jinghzhu/KubernetesCRD/pkg/crd/jinghzhu/v1/apis/clientset/versioned"
+	jinghzhuinformers "github.com/jinghzhu/KubernetesCRD/pkg/crd/jinghzhu/v1/apis/informers/externalversions"
+)
+
+func main() {
+	resync := flag.Duration("resync", 30*time.Second, "How often the informers resync all Jinghzhu instances and Pods.")
+	workers := flag.Int("workers", 2, "How many Jinghzhu instances are reconciled at the same time.")
+	maxRetries := flag.Int("max-retries", controller.DefaultMaxRetries, "How many times a failed Jinghzhu instance is retried before its failure is recorded in its status.")
+	leaderElect := flag.Bool("leader-elect", true, "Hold a Lease in the CRD namespace before reconciling, so only one replica runs at a time.")
+	leaseDuration := flag.Duration("lease-duration", controller.DefaultLeaseDuration, "How long the other replicas wait before they take over an unrenewed Lease.")
+	renewDeadline := flag.Duration("renew-deadline", controller.DefaultRenewDeadline, "How long the leader keeps retrying to renew the Lease before it stops.")
+	retryPeriod := flag.Duration("retry-period", controller.DefaultRetryPeriod, "The interval between two attempts to acquire or renew the Lease.")
+	releaseOnCancel := flag.Bool("release-on-cancel", false, "Release the Lease on shutdown so another replica can take over right away.")
+	flag.Parse()
+
+	cfg := config.GetConfig()
+	kubeconfigPath := cfg.GetKubeconfigPath()
+	namespace := cfg.GetCRDNamespace()
+
+	// Use kubeconfig to create client config.
+	clientConfig, err := clientcmd.BuildConfigFromFlags("", kubeconfigPath)
+	if err != nil {
+		panic(err)
+	}
+	kubeClient, err := kubernetes.NewForConfig(clientConfig)
+	if err != nil {
+		panic(err)
+	}
+	jinghzhuClient, err := jinghzhuclientset.NewForConfig(clientConfig)
+	if err != nil {
+		panic(err)
+	}
+
+	jinghzhuInformerFactory := jinghzhuinformers.NewSharedInformerFactoryWithOptions(jinghzhuClient, *resync,
+		jinghzhuinformers.WithNamespace(namespace))
+	// Watch all Pods in the namespace rather than the labelled ones, so the controller notices the
+	// owned Pods whose label has been removed and releases them.
+	kubeInformerFactory := informers.NewSharedInformerFactoryWithOptions(kubeClient, *resync,
+		informers.WithNamespace(namespace))
+
+	c := controller.NewController(kubeClient, jinghzhuClient,
+		jinghzhuInformerFactory.Jinghzhu().V1().Jinghzhus(),
+		kubeInformerFactory.Core().V1().Pods(),
+		controller.Options{
+			Queue: controller.QueueOptions{MaxRetries: *maxRetries},
+		})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-signals
+		cancel()
+	}()
+
+	// Start the informers before the election, so a new leader can work with warm caches.
+	jinghzhuInformerFactory.Start(ctx.Done())
+	kubeInformerFactory.Start(ctx.Done())
+	run := func(stopCh <-chan struct{}) error {
+		return c.Run(*workers, stopCh)
+	}
+	if !*leaderElect {
+		err = run(ctx.Done())
+	} else {
+		err = controller.RunWithLeaderElection(ctx, kubeClient, controller.LeaderElectionOptions{
+			Namespace:       namespace,
+			LeaseDuration:   *leaseDuration,
+			RenewDeadline:   *renewDeadline,
+			RetryPeriod:     *retryPeriod,
+			ReleaseOnCancel: *releaseOnCancel,
+		}, run)
+	}
+	if err != nil {
+		panic(err)
+	}
+}
@@ -1,7 +1,9 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"time"
 
 	"github.com/jinghzhu/KubernetesCRD/pkg/config"
 	"github.com/jinghzhu/KubernetesCRD/pkg/types"
@@ -15,7 +17,7 @@ import (
 )
 
 func main() {
-	ctx := types.GetCtx()
+	ctx := context.Background()
 	cfg := config.GetConfig()
 	kubeconfigPath := cfg.GetKubeconfigPath()
 
@@ -30,10 +32,16 @@ func main() {
 		panic(err)
 	}
 
-	// Init a CRD kind.
-	if _, err = crdjinghzhuv1.CreateCustomResourceDefinition(apiextensionsClientSet); err != nil {
+	// Init a CRD kind, or upgrade it if it exists with an older definition.
+	crdCtx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+	_, diffs, err := crdjinghzhuv1.EnsureCustomResourceDefinition(crdCtx, apiextensionsClientSet)
+	if err != nil {
 		panic(err)
 	}
+	for _, diff := range diffs {
+		fmt.Println("CRD Jinghzhu updated: " + diff.String())
+	}
 
 	// Create a CRD client interface for Jinghzhu v1.
 	crdClient, err := jinghzhuv1client.NewClient(ctx, kubeconfigPath, cfg.GetCRDNamespace())
@@ -52,12 +60,8 @@ func main() {
 			Current: 0,
 			PodList: make([]string, 0),
 		},
-		Status: crdjinghzhuv1.JinghzhuStatus{
-			State:   types.StatePending,
-			Message: "Created but not processed yet",
-		},
 	}
-	result, err := crdClient.CreateDefault(exampleInstance)
+	result, err := crdClient.CreateWithContext(ctx, exampleInstance, metav1.CreateOptions{})
 	if err != nil && apierrors.IsAlreadyExists(err) {
 		fmt.Printf("ALREADY EXISTS: %#v\n", result)
 	} else if err != nil {
@@ -66,15 +70,36 @@ func main() {
 	crdInstanceName := result.GetName()
 	fmt.Println("CREATED: " + result.String())
 
-	// Wait until the CRD object is handled by controller and its status is changed to Processed.
-	err = crdClient.WaitForInstanceProcessed(crdInstanceName)
+	// Status is a subresource, so it is ignored on creation and has to be set separately. Only set it if
+	// the controller hasn't processed the instance yet, otherwise its state would be moved back. MutateStatus
+	// checks the move to Pending and records it in the state history.
+	result, err = crdClient.MutateStatus(ctx, crdInstanceName, func(instance *crdjinghzhuv1.Jinghzhu) error {
+		if instance.Status.State == "" {
+			instance.Status.State = types.StatePending
+			instance.Status.Message = "Created but not processed yet"
+		}
+
+		return nil
+	})
 	if err != nil {
 		panic(err)
 	}
-	fmt.Println("Processed " + crdInstanceName)
+
+	// Wait until the CRD object is handled by controller and its state moves on from Pending.
+	waitCtx, cancelWait := context.WithTimeout(ctx, 30*time.Second)
+	defer cancelWait()
+	_, err = crdClient.WaitForInstanceProcessed(waitCtx, crdInstanceName)
+	if timeoutErr, ok := err.(*jinghzhuv1client.WaitTimeoutError); ok {
+		// Nothing processes the instance if no controller is running. It's not fatal for this example.
+		fmt.Println("Not processed yet, is the controller running? " + timeoutErr.Error())
+	} else if err != nil {
+		panic(err)
+	} else {
+		fmt.Println("Processed " + crdInstanceName)
+	}
 
 	// Get the list of CRs.
-	exampleList, err := crdClient.List(metav1.ListOptions{})
+	exampleList, err := crdClient.ListWithContext(ctx, metav1.ListOptions{})
 	if err != nil {
 		panic(err)
 	}
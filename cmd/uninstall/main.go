@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"time"
+
+	"github.com/jinghzhu/KubernetesCRD/pkg/config"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/clientcmd"
+
+	crdjinghzhuv1 "github.com/jinghzhu/KubernetesCRD/pkg/crd/jinghzhu/v1"
+	apiextensionsclient "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+)
+
+func main() {
+	policy := flag.String("policy", string(crdjinghzhuv1.DeletionPolicyRefuse),
+		"What to do with remaining Jinghzhu instances: refuse, cascade or force.")
+	timeout := flag.Duration("timeout", 2*time.Minute, "How long to wait for the CRD to disappear.")
+	flag.Parse()
+
+	cfg := config.GetConfig()
+	kubeconfigPath := cfg.GetKubeconfigPath()
+
+	// Use kubeconfig to create client config.
+	clientConfig, err := clientcmd.BuildConfigFromFlags("", kubeconfigPath)
+	if err != nil {
+		panic(err)
+	}
+
+	apiextensionsClientSet, err := apiextensionsclient.NewForConfig(clientConfig)
+	if err != nil {
+		panic(err)
+	}
+	dynamicClient, err := dynamic.NewForConfig(clientConfig)
+	if err != nil {
+		panic(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	err = crdjinghzhuv1.DeleteCustomResourceDefinition(ctx, apiextensionsClientSet, dynamicClient, crdjinghzhuv1.DeletionPolicy(*policy))
+	if err != nil {
+		panic(err)
+	}
+}
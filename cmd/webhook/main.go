@@ -0,0 +1,24 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+
+	"github.com/jinghzhu/KubernetesCRD/pkg/crd/jinghzhu/conversion"
+)
+
+func main() {
+	port := flag.Int("port", 8443, "The port to serve the conversion webhook on.")
+	certFile := flag.String("tls-cert-file", "/etc/webhook/certs/tls.crt", "The certificate of the webhook, signed by the CA in CRD_CONVERSION_WEBHOOK_CA_BUNDLE.")
+	keyFile := flag.String("tls-private-key-file", "/etc/webhook/certs/tls.key", "The private key of the certificate.")
+	flag.Parse()
+
+	mux := http.NewServeMux()
+	mux.Handle(conversion.WebhookPath, conversion.NewWebhookHandler())
+	addr := fmt.Sprintf(":%d", *port)
+	fmt.Printf("Serve Jinghzhu conversion webhook at %s%s\n", addr, conversion.WebhookPath)
+	if err := http.ListenAndServeTLS(addr, *certFile, *keyFile, mux); err != nil {
+		panic(err)
+	}
+}
@@ -3,6 +3,8 @@ module github.com/jinghzhu/KubernetesCRD
 go 1.15
 
 require (
+	github.com/evanphx/json-patch v4.9.0+incompatible
+	k8s.io/api v0.18.12
 	k8s.io/apiextensions-apiserver v0.18.12
 	k8s.io/apimachinery v0.18.12
 	k8s.io/client-go v0.18.12
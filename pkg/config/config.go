@@ -17,6 +17,9 @@ func initConfig() {
 	if config.kubeconfigPath == "" {
 		config.kubeconfigPath = DefaultKubeconfigPath
 	}
+
+	config.conversionWebhookService = os.Getenv("CRD_CONVERSION_WEBHOOK_SERVICE")
+	config.conversionWebhookCABundle = os.Getenv("CRD_CONVERSION_WEBHOOK_CA_BUNDLE")
 }
 
 func GetConfig() *Config {
@@ -12,8 +12,10 @@ const (
 )
 
 type Config struct {
-	crdNamespace   string
-	kubeconfigPath string
+	crdNamespace              string
+	kubeconfigPath            string
+	conversionWebhookService  string
+	conversionWebhookCABundle string
 }
 
 func (c *Config) GetCRDNamespace() string {
@@ -23,3 +25,15 @@ func (c *Config) GetCRDNamespace() string {
 func (c *Config) GetKubeconfigPath() string {
 	return c.kubeconfigPath
 }
+
+// GetConversionWebhookService returns the Service of the CRD conversion webhook in the format of
+// namespace/name. It is empty if the webhook isn't deployed.
+func (c *Config) GetConversionWebhookService() string {
+	return c.conversionWebhookService
+}
+
+// GetConversionWebhookCABundle returns the PEM encoded CA which signs the certificate of the CRD
+// conversion webhook.
+func (c *Config) GetConversionWebhookCABundle() string {
+	return c.conversionWebhookCABundle
+}
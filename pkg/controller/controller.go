@@ -0,0 +1,215 @@
+// Package controller reconciles Jinghzhu instances. It creates and deletes Pods so the number of
+// running Pods of every Jinghzhu matches Spec.Desired, and keeps its observed fields up to date.
+package controller
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	jinghzhuv1 "github.com/jinghzhu/KubernetesCRD/pkg/crd/jinghzhu/v1"
+	"github.com/jinghzhu/KubernetesCRD/pkg/crd/jinghzhu/v1/apis/clientset/versioned"
+	jinghzhuv1informers "github.com/jinghzhu/KubernetesCRD/pkg/crd/jinghzhu/v1/apis/informers/externalversions/jinghzhu/v1"
+	jinghzhuv1listers "github.com/jinghzhu/KubernetesCRD/pkg/crd/jinghzhu/v1/apis/listers/jinghzhu/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	coreinformers "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/kubernetes"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	// InstanceLabel is the label put on every Pod created for a Jinghzhu. Its value is the Jinghzhu name.
+	InstanceLabel string = "jinghzhu.io/instance"
+)
+
+// Options configures a Controller.
+type Options struct {
+	// PodTemplate describes the Pods to create for every Jinghzhu. If it is nil, DefaultPodTemplate is used.
+	PodTemplate *corev1.PodTemplateSpec
+	// Queue configures the retries of the Jinghzhu instances which fail to reconcile. Its OnFailure
+	// and UpdateFilter are set by the controller.
+	Queue QueueOptions
+}
+
+// Controller reconciles Jinghzhu instances with their Pods.
+type Controller struct {
+	kubeClient     kubernetes.Interface
+	jinghzhuClient versioned.Interface
+
+	jinghzhuLister  jinghzhuv1listers.JinghzhuLister
+	jinghzhusSynced cache.InformerSynced
+	podLister       corelisters.PodLister
+	podsSynced      cache.InformerSynced
+
+	podTemplate  corev1.PodTemplateSpec
+	queue        *Queue
+	expectations *expectations
+}
+
+// NewController returns a Controller built on the given informers.
+func NewController(kubeClient kubernetes.Interface, jinghzhuClient versioned.Interface, jinghzhuInformer jinghzhuv1informers.JinghzhuInformer, podInformer coreinformers.PodInformer, options Options) *Controller {
+	podTemplate := options.PodTemplate
+	if podTemplate == nil {
+		podTemplate = DefaultPodTemplate()
+	}
+	c := &Controller{
+		kubeClient:      kubeClient,
+		jinghzhuClient:  jinghzhuClient,
+		jinghzhuLister:  jinghzhuInformer.Lister(),
+		jinghzhusSynced: jinghzhuInformer.Informer().HasSynced,
+		podLister:       podInformer.Lister(),
+		podsSynced:      podInformer.Informer().HasSynced,
+		podTemplate:     *podTemplate,
+		expectations:    newExpectations(),
+	}
+	queueOptions := options.Queue
+	if queueOptions.Name == "" {
+		queueOptions.Name = jinghzhuv1.Plural
+	}
+	queueOptions.OnFailure = c.recordFailure
+	queueOptions.UpdateFilter = specChanged
+	c.queue = NewQueue(c.Reconcile, queueOptions)
+
+	jinghzhuInformer.Informer().AddEventHandler(c.queue.EventHandler())
+	podInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			c.handlePod(obj, 1, 0)
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldPod, newPod := oldObj.(*corev1.Pod), newObj.(*corev1.Pod)
+			if reflect.DeepEqual(oldPod, newPod) {
+				// Periodic resyncs are handled through the Jinghzhu informer.
+				return
+			}
+			if oldPod.GetDeletionTimestamp() == nil && newPod.GetDeletionTimestamp() != nil {
+				c.handlePod(newObj, 0, 1)
+			} else {
+				c.handlePod(newObj, 0, 0)
+			}
+			// The old owner needs to know if the Pod has been released or relabelled.
+			if !reflect.DeepEqual(metav1.GetControllerOf(oldPod), metav1.GetControllerOf(newPod)) ||
+				!reflect.DeepEqual(oldPod.GetLabels(), newPod.GetLabels()) {
+				c.handlePod(oldObj, 0, 0)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			c.handlePod(obj, 0, 1)
+		},
+	})
+
+	return c
+}
+
+// Run waits for the informer caches to sync and then reconciles Jinghzhu instances with the given
+// number of workers until stopCh is closed. Closing stopCh also cancels the API requests in flight.
+func (c *Controller) Run(workers int, stopCh <-chan struct{}) error {
+	defer utilruntime.HandleCrash()
+
+	fmt.Println("Starting Jinghzhu controller")
+	if ok := cache.WaitForCacheSync(stopCh, c.jinghzhusSynced, c.podsSynced); !ok {
+		return fmt.Errorf("fail to wait for caches to sync")
+	}
+
+	fmt.Printf("Start %d workers\n", workers)
+	c.queue.Run(workers, stopCh)
+	fmt.Println("Stopping Jinghzhu controller")
+
+	return nil
+}
+
+// handlePod puts the key of the Jinghzhu which the Pod belongs to into the queue. That is its
+// controller if it has one, or otherwise the Jinghzhu named by its label, which may adopt it. adds and
+// deletes are the numbers of Pod creations and deletions the event stands for.
+func (c *Controller) handlePod(obj interface{}, adds, deletes int) {
+	pod, ok := obj.(*corev1.Pod)
+	if !ok {
+		tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+		if !ok {
+			return
+		}
+		if pod, ok = tombstone.Obj.(*corev1.Pod); !ok {
+			return
+		}
+	}
+
+	var owner string
+	if ref := metav1.GetControllerOf(pod); ref != nil {
+		instance := c.resolveControllerRef(pod.GetNamespace(), ref)
+		if instance == nil {
+			return
+		}
+		owner = instance.GetName()
+	} else if owner, ok = pod.GetLabels()[InstanceLabel]; !ok {
+		return
+	}
+	key := pod.GetNamespace() + "/" + owner
+	c.expectations.observe(key, adds, deletes)
+	c.queue.Add(key)
+}
+
+// resolveControllerRef returns the Jinghzhu the OwnerReference points to, or nil if it doesn't point
+// to a Jinghzhu or the Jinghzhu doesn't exist anymore.
+func (c *Controller) resolveControllerRef(namespace string, ref *metav1.OwnerReference) *jinghzhuv1.Jinghzhu {
+	if ref.Kind != controllerKind.Kind || ref.APIVersion != controllerKind.GroupVersion().String() {
+		return nil
+	}
+	instance, err := c.jinghzhuLister.Jinghzhus(namespace).Get(ref.Name)
+	if err != nil || instance.GetUID() != ref.UID {
+		return nil
+	}
+
+	return instance
+}
+
+// recordFailure puts the error of a Jinghzhu which has used up its retries into its status. The state
+// is left alone, because Failed is final and the next change of the Jinghzhu is reconciled again.
+func (c *Controller) recordFailure(ctx context.Context, key string, reconcileErr error) {
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return
+	}
+	instance, err := c.jinghzhuLister.Jinghzhus(namespace).Get(name)
+	if err != nil {
+		return
+	}
+	updated := instance.DeepCopy()
+	updated.Status.Message = fmt.Sprintf("fail to reconcile: %v", reconcileErr)
+	jinghzhuv1.SetStatusCondition(&updated.Status.Conditions, jinghzhuv1.JinghzhuCondition{
+		Type:               jinghzhuv1.ConditionDegraded,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: updated.GetGeneration(),
+		Reason:             "ReconcileFailed",
+		Message:            updated.Status.Message,
+	})
+	_, err = c.jinghzhuClient.JinghzhuV1().Jinghzhus(namespace).UpdateStatus(ctx, updated, metav1.UpdateOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		utilruntime.HandleError(fmt.Errorf("fail to record failure of Jinghzhu %s: %v", key, err))
+	}
+}
+
+// specChanged drops the updates of a Jinghzhu which only touch its status, e.g. the ones written by
+// the controller itself. Otherwise recording a failure would start the retries all over again.
+// Periodic resyncs, which don't change anything, are kept.
+func specChanged(oldObj, newObj interface{}) bool {
+	oldInstance, ok := oldObj.(*jinghzhuv1.Jinghzhu)
+	if !ok {
+		return true
+	}
+	newInstance, ok := newObj.(*jinghzhuv1.Jinghzhu)
+	if !ok {
+		return true
+	}
+	if reflect.DeepEqual(oldInstance, newInstance) {
+		return true
+	}
+	oldCopy, newCopy := oldInstance.DeepCopy(), newInstance.DeepCopy()
+	oldCopy.Status, newCopy.Status = jinghzhuv1.JinghzhuStatus{}, jinghzhuv1.JinghzhuStatus{}
+	oldCopy.ResourceVersion, newCopy.ResourceVersion = "", ""
+	oldCopy.ManagedFields, newCopy.ManagedFields = nil, nil
+
+	return !reflect.DeepEqual(oldCopy, newCopy)
+}
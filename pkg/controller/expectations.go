@@ -0,0 +1,67 @@
+package controller
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// expectationsTimeout is how long the controller waits for the informer to observe the Pods it
+	// created or deleted before it stops trusting its expectations.
+	expectationsTimeout time.Duration = 5 * time.Minute
+)
+
+// expectations records the Pod creations and deletions the controller has issued for every Jinghzhu
+// but the Pod informer hasn't observed yet. While they are pending the cache is stale, so the
+// controller doesn't create or delete more Pods based on it.
+type expectations struct {
+	sync.Mutex
+	items map[string]*expectation
+}
+
+type expectation struct {
+	adds      int
+	deletes   int
+	timestamp time.Time
+}
+
+func newExpectations() *expectations {
+	return &expectations{items: make(map[string]*expectation)}
+}
+
+// expect records that adds Pods are being created and deletes Pods are being deleted for the key.
+func (e *expectations) expect(key string, adds, deletes int) {
+	e.Lock()
+	defer e.Unlock()
+	e.items[key] = &expectation{adds: adds, deletes: deletes, timestamp: time.Now()}
+}
+
+// observe lowers the pending numbers of the key after the informer has seen a Pod change, or after
+// a creation or deletion has failed and will never be seen.
+func (e *expectations) observe(key string, adds, deletes int) {
+	e.Lock()
+	defer e.Unlock()
+	if exp, ok := e.items[key]; ok {
+		exp.adds -= adds
+		exp.deletes -= deletes
+	}
+}
+
+// satisfied tells whether the cache has caught up with everything the controller did for the key.
+func (e *expectations) satisfied(key string) bool {
+	e.Lock()
+	defer e.Unlock()
+	exp, ok := e.items[key]
+	if !ok {
+		return true
+	}
+
+	return (exp.adds <= 0 && exp.deletes <= 0) || time.Since(exp.timestamp) > expectationsTimeout
+}
+
+// forget drops the expectations of the key, e.g. after the Jinghzhu is deleted.
+func (e *expectations) forget(key string) {
+	e.Lock()
+	defer e.Unlock()
+	delete(e.items, key)
+}
@@ -0,0 +1,185 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/jinghzhu/KubernetesCRD/pkg/config"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+const (
+	// DefaultLeaseName is the name of the Lease which the controller replicas compete for.
+	DefaultLeaseName string = "jinghzhu-controller"
+	// DefaultLeaseDuration is how long the other replicas wait before they take over an unrenewed Lease.
+	DefaultLeaseDuration time.Duration = 15 * time.Second
+	// DefaultRenewDeadline is how long the leader keeps retrying to renew the Lease before it gives up.
+	DefaultRenewDeadline time.Duration = 10 * time.Second
+	// DefaultRetryPeriod is the interval between two attempts to acquire or renew the Lease.
+	DefaultRetryPeriod time.Duration = 2 * time.Second
+)
+
+// LeaderElectionOptions configures RunWithLeaderElection. Zero values are replaced by the defaults.
+type LeaderElectionOptions struct {
+	// Namespace of the Lease. It defaults to config.GetCRDNamespace().
+	Namespace string
+	// Name of the Lease. It defaults to DefaultLeaseName.
+	Name string
+	// Identity of this replica. It defaults to the hostname plus a random suffix.
+	Identity      string
+	LeaseDuration time.Duration
+	RenewDeadline time.Duration
+	RetryPeriod   time.Duration
+	// ReleaseOnCancel releases the Lease when ctx is done, so another replica can take over right away
+	// instead of waiting for LeaseDuration. The Lease is released only after run has returned.
+	ReleaseOnCancel bool
+}
+
+// LeadershipLostError is returned by RunWithLeaderElection when the Lease is lost before ctx is done.
+type LeadershipLostError struct {
+	Namespace string
+	Name      string
+	Identity  string
+}
+
+func (e *LeadershipLostError) Error() string {
+	return fmt.Sprintf("%s lost Lease %s/%s", e.Identity, e.Namespace, e.Name)
+}
+
+// RunWithLeaderElection blocks until this replica holds the Lease, then calls run. stopCh of run is
+// closed when ctx is done or the leadership is lost, and RunWithLeaderElection only returns after run
+// has returned, so the workers never outlive the leadership. If the leadership is lost, the error is
+// *LeadershipLostError. If ctx is done first, the error is the one returned by run, if any.
+func RunWithLeaderElection(ctx context.Context, kubeClient kubernetes.Interface, options LeaderElectionOptions, run func(stopCh <-chan struct{}) error) error {
+	if options.Namespace == "" {
+		options.Namespace = config.GetConfig().GetCRDNamespace()
+	}
+	if options.Name == "" {
+		options.Name = DefaultLeaseName
+	}
+	if options.Identity == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return err
+		}
+		options.Identity = hostname + "_" + string(uuid.NewUUID())
+	}
+	if options.LeaseDuration <= 0 {
+		options.LeaseDuration = DefaultLeaseDuration
+	}
+	if options.RenewDeadline <= 0 {
+		options.RenewDeadline = DefaultRenewDeadline
+	}
+	if options.RetryPeriod <= 0 {
+		options.RetryPeriod = DefaultRetryPeriod
+	}
+
+	lock, err := resourcelock.New(resourcelock.LeasesResourceLock, options.Namespace, options.Name,
+		kubeClient.CoreV1(), kubeClient.CoordinationV1(), resourcelock.ResourceLockConfig{Identity: options.Identity})
+	if err != nil {
+		return err
+	}
+
+	// The election runs with its own context. It is only cancelled after run has returned, so the
+	// Lease is never released while the workers are still running.
+	electionCtx, cancelElection := context.WithCancel(context.Background())
+	defer cancelElection()
+	var (
+		mu       sync.Mutex
+		running  bool
+		finished bool
+		runErr   error
+	)
+	runDone := make(chan struct{})
+
+	elector, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+		Lock:            lock,
+		Name:            options.Name,
+		LeaseDuration:   options.LeaseDuration,
+		RenewDeadline:   options.RenewDeadline,
+		RetryPeriod:     options.RetryPeriod,
+		ReleaseOnCancel: options.ReleaseOnCancel,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(leaderCtx context.Context) {
+				mu.Lock()
+				// The elector calls it in a goroutine, so the election may already be over.
+				if ctx.Err() != nil || finished {
+					mu.Unlock()
+
+					return
+				}
+				running = true
+				mu.Unlock()
+				defer close(runDone)
+				defer cancelElection()
+
+				fmt.Printf("%s acquired Lease %s/%s\n", options.Identity, options.Namespace, options.Name)
+				stopCh := make(chan struct{})
+				go func() {
+					select {
+					case <-leaderCtx.Done():
+					case <-ctx.Done():
+					}
+					close(stopCh)
+				}()
+				runErr = run(stopCh)
+			},
+			OnStoppedLeading: func() {
+				// It is also called when the election ends before the Lease was ever acquired.
+				mu.Lock()
+				defer mu.Unlock()
+				if !running {
+					return
+				}
+				fmt.Printf("%s stopped leading Lease %s/%s\n", options.Identity, options.Namespace, options.Name)
+			},
+			OnNewLeader: func(identity string) {
+				if identity != options.Identity {
+					fmt.Printf("Lease %s/%s is held by %s\n", options.Namespace, options.Name, identity)
+				}
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-electionCtx.Done():
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		// If run has started, it cancels the election itself once it returns.
+		if !running {
+			cancelElection()
+		}
+	}()
+
+	elector.Run(electionCtx)
+
+	mu.Lock()
+	finished = true
+	started := running
+	mu.Unlock()
+	if !started {
+		return nil
+	}
+	<-runDone
+	if runErr != nil {
+		return runErr
+	}
+	if ctx.Err() == nil {
+		return &LeadershipLostError{Namespace: options.Namespace, Name: options.Name, Identity: options.Identity}
+	}
+
+	return nil
+}
@@ -0,0 +1,68 @@
+package controller
+
+import (
+	jinghzhuv1 "github.com/jinghzhu/KubernetesCRD/pkg/crd/jinghzhu/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// DefaultPodImage is the image of the Pods created by DefaultPodTemplate.
+	DefaultPodImage string = "k8s.gcr.io/pause:3.2"
+)
+
+// DefaultPodTemplate returns the template of the Pods created for a Jinghzhu if the controller is not
+// given one. It runs a single pause container.
+func DefaultPodTemplate() *corev1.PodTemplateSpec {
+	return &corev1.PodTemplateSpec{
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{
+					Name:  "jinghzhu",
+					Image: DefaultPodImage,
+				},
+			},
+		},
+	}
+}
+
+// newPod returns a Pod for the Jinghzhu instance built from the template.
+func newPod(instance *jinghzhuv1.Jinghzhu, template *corev1.PodTemplateSpec) *corev1.Pod {
+	podLabels := labels.Set{}
+	for k, v := range template.GetLabels() {
+		podLabels[k] = v
+	}
+	podLabels[InstanceLabel] = instance.GetName()
+
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: instance.GetName() + "-",
+			Namespace:    instance.GetNamespace(),
+			Labels:       podLabels,
+			Annotations:  template.GetAnnotations(),
+			OwnerReferences: []metav1.OwnerReference{
+				*metav1.NewControllerRef(instance, controllerKind),
+			},
+		},
+		Spec: *template.Spec.DeepCopy(),
+	}
+}
+
+// selectorFor returns the label selector of the Pods of the Jinghzhu instance.
+func selectorFor(instance *jinghzhuv1.Jinghzhu) labels.Selector {
+	return labels.SelectorFromSet(labels.Set{InstanceLabel: instance.GetName()})
+}
+
+// isPodActive tells whether the Pod counts towards Spec.Desired, i.e. it is neither terminated nor
+// being deleted.
+func isPodActive(pod *corev1.Pod) bool {
+	return pod.GetDeletionTimestamp() == nil &&
+		pod.Status.Phase != corev1.PodSucceeded &&
+		pod.Status.Phase != corev1.PodFailed
+}
+
+// isPodRunning tells whether the Pod is active and running.
+func isPodRunning(pod *corev1.Pod) bool {
+	return isPodActive(pod) && pod.Status.Phase == corev1.PodRunning
+}
@@ -0,0 +1,179 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+)
+
+const (
+	// DefaultBaseDelay is the delay before the first retry of a failed key.
+	DefaultBaseDelay time.Duration = 5 * time.Millisecond
+	// DefaultMaxDelay caps the delay between two retries of a failed key.
+	DefaultMaxDelay time.Duration = 5 * time.Minute
+	// DefaultMaxRetries is how many times a failed key is retried before it is given up.
+	DefaultMaxRetries int = 10
+)
+
+// ReconcileFunc processes the key of an object in the format of namespace/name. ctx is cancelled when
+// the queue is stopped, so the API requests in flight don't outlive it.
+type ReconcileFunc func(ctx context.Context, key string) error
+
+// FailureFunc is called with the last error once a key has used up its retries.
+type FailureFunc func(ctx context.Context, key string, err error)
+
+// UpdateFilter tells whether an update event needs processing. Returning false drops the event.
+type UpdateFilter func(oldObj, newObj interface{}) bool
+
+// QueueOptions configures a Queue. Zero values are replaced by the defaults.
+type QueueOptions struct {
+	// Name is shown in the metrics of the underlying workqueue.
+	Name string
+	// BaseDelay and MaxDelay bound the exponential per-key backoff.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// MaxRetries is how many times a failed key is retried. A negative value disables retries.
+	MaxRetries int
+	// OnFailure is called when a key is given up. It is optional.
+	OnFailure FailureFunc
+	// UpdateFilter drops the update events which don't need processing. It is optional.
+	UpdateFilter UpdateFilter
+}
+
+// Queue turns informer events into namespace/name keys and feeds them to a ReconcileFunc from a
+// rate-limited workqueue. A key is never processed by two workers at the same time, and a key which
+// fails is retried with exponential backoff until it succeeds or runs out of retries.
+type Queue struct {
+	queue     workqueue.RateLimitingInterface
+	reconcile ReconcileFunc
+	options   QueueOptions
+}
+
+// NewQueue returns a Queue which processes keys with reconcile.
+func NewQueue(reconcile ReconcileFunc, options QueueOptions) *Queue {
+	if options.BaseDelay <= 0 {
+		options.BaseDelay = DefaultBaseDelay
+	}
+	if options.MaxDelay <= 0 {
+		options.MaxDelay = DefaultMaxDelay
+	}
+	if options.MaxRetries == 0 {
+		options.MaxRetries = DefaultMaxRetries
+	}
+	rateLimiter := workqueue.NewItemExponentialFailureRateLimiter(options.BaseDelay, options.MaxDelay)
+
+	return &Queue{
+		queue:     workqueue.NewNamedRateLimitingQueue(rateLimiter, options.Name),
+		reconcile: reconcile,
+		options:   options,
+	}
+}
+
+// Add puts the key into the queue right away.
+func (q *Queue) Add(key string) {
+	q.queue.Add(key)
+}
+
+// Enqueue puts the key of the object, which may be a cache.DeletedFinalStateUnknown, into the queue.
+func (q *Queue) Enqueue(obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		utilruntime.HandleError(err)
+
+		return
+	}
+	q.queue.Add(key)
+}
+
+// EventHandler returns the handler to register on an informer. It only enqueues keys, so it never
+// blocks the informer.
+func (q *Queue) EventHandler() cache.ResourceEventHandler {
+	return cache.ResourceEventHandlerFuncs{
+		AddFunc: q.Enqueue,
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			if q.options.UpdateFilter != nil && !q.options.UpdateFilter(oldObj, newObj) {
+				return
+			}
+			q.Enqueue(newObj)
+		},
+		DeleteFunc: q.Enqueue,
+	}
+}
+
+// Len returns the number of keys waiting to be processed.
+func (q *Queue) Len() int {
+	return q.queue.Len()
+}
+
+// Run starts workers goroutines to process keys and blocks until stopCh is closed. Then it shuts the
+// queue down, cancels the context of the keys in process and waits for them to finish.
+func (q *Queue) Run(workers int, stopCh <-chan struct{}) {
+	defer utilruntime.HandleCrash()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			wait.Until(func() { q.runWorker(ctx) }, time.Second, stopCh)
+		}()
+	}
+
+	<-stopCh
+	q.queue.ShutDown()
+	cancel()
+	wg.Wait()
+}
+
+func (q *Queue) runWorker(ctx context.Context) {
+	for q.processNextItem(ctx) {
+	}
+}
+
+func (q *Queue) processNextItem(ctx context.Context) bool {
+	item, shutdown := q.queue.Get()
+	if shutdown {
+		return false
+	}
+	defer q.queue.Done(item)
+
+	key := item.(string)
+	err := q.reconcile(ctx, key)
+	if err != nil && ctx.Err() != nil {
+		// Stopped in the middle. The key isn't given up, whoever runs next reconciles it again.
+		return true
+	}
+	q.handleErr(ctx, key, err)
+
+	return true
+}
+
+// handleErr forgets the key on success, and otherwise retries it with backoff until it runs out of
+// retries.
+func (q *Queue) handleErr(ctx context.Context, key string, err error) {
+	if err == nil {
+		q.queue.Forget(key)
+
+		return
+	}
+	retries := q.queue.NumRequeues(key)
+	if retries < q.options.MaxRetries {
+		fmt.Printf("Fail to process %s, retry %d/%d: %v\n", key, retries+1, q.options.MaxRetries, err)
+		q.queue.AddRateLimited(key)
+
+		return
+	}
+
+	q.queue.Forget(key)
+	utilruntime.HandleError(fmt.Errorf("give up %s after %d retries: %v", key, retries, err))
+	if q.options.OnFailure != nil {
+		q.options.OnFailure(ctx, key, err)
+	}
+}
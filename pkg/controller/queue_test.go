@@ -0,0 +1,77 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+func TestQueueStopCancelsReconcile(t *testing.T) {
+	started := make(chan struct{})
+	failed := make(chan string, 1)
+	q := NewQueue(func(ctx context.Context, key string) error {
+		close(started)
+		<-ctx.Done()
+
+		return ctx.Err()
+	}, QueueOptions{
+		MaxRetries: -1,
+		OnFailure: func(ctx context.Context, key string, err error) {
+			failed <- key
+		},
+	})
+	q.Add("crd/a")
+
+	stopCh := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		q.Run(1, stopCh)
+		close(stopped)
+	}()
+	<-started
+	close(stopCh)
+
+	select {
+	case <-stopped:
+	case <-time.After(wait.ForeverTestTimeout):
+		t.Fatal("Run didn't return after stopCh was closed")
+	}
+	select {
+	case key := <-failed:
+		t.Errorf("%s was given up, want it left for the next run", key)
+	default:
+	}
+}
+
+func TestQueueRetries(t *testing.T) {
+	calls := 0
+	failed := make(chan error, 1)
+	q := NewQueue(func(ctx context.Context, key string) error {
+		calls++
+
+		return fmt.Errorf("attempt %d", calls)
+	}, QueueOptions{
+		BaseDelay:  time.Millisecond,
+		MaxRetries: 2,
+		OnFailure: func(ctx context.Context, key string, err error) {
+			failed <- err
+		},
+	})
+	q.Add("crd/a")
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go q.Run(1, stopCh)
+
+	select {
+	case err := <-failed:
+		if err.Error() != "attempt 3" {
+			t.Errorf("OnFailure got %v, want the error of the last of 3 attempts", err)
+		}
+	case <-time.After(wait.ForeverTestTimeout):
+		t.Fatal("OnFailure wasn't called")
+	}
+}
@@ -0,0 +1,288 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+
+	jinghzhuv1 "github.com/jinghzhu/KubernetesCRD/pkg/crd/jinghzhu/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	errors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/jinghzhu/KubernetesCRD/pkg/types"
+)
+
+// Reconcile brings the Pods of the Jinghzhu identified by key, in the format of namespace/name, to
+// Spec.Desired, then records the observed Pods in Status. The API requests are cancelled when ctx is
+// done.
+func (c *Controller) Reconcile(ctx context.Context, key string) error {
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return err
+	}
+	instance, err := c.jinghzhuLister.Jinghzhus(namespace).Get(name)
+	if apierrors.IsNotFound(err) {
+		// The garbage collector deletes the Pods through their OwnerReferences.
+		c.expectations.forget(key)
+
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if instance.GetDeletionTimestamp() != nil {
+		return c.finalize(ctx, key, instance)
+	}
+	if !jinghzhuv1.ContainsFinalizer(instance, jinghzhuv1.FinalizerPodCleanup) {
+		// The instance was not created by the client, e.g. by kubectl.
+		updated := instance.DeepCopy()
+		jinghzhuv1.AddFinalizer(updated, jinghzhuv1.FinalizerPodCleanup)
+		instance, err = c.jinghzhuClient.JinghzhuV1().Jinghzhus(namespace).Update(ctx, updated, metav1.UpdateOptions{})
+		if err != nil {
+			return err
+		}
+	}
+
+	if instance.Status.State.IsFinal() {
+		// Nothing can follow Succeeded or Failed but the deletion, so the Pods are left as they are.
+		return nil
+	}
+
+	// List all Pods rather than the matching ones, so the Pods which don't match anymore are released.
+	pods, err := c.podLister.Pods(namespace).List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	pods, err = newPodRefManager(c, instance).claimPods(ctx, pods)
+	if err != nil {
+		return err
+	}
+	active := make([]*corev1.Pod, 0, len(pods))
+	for _, pod := range pods {
+		if isPodActive(pod) {
+			active = append(active, pod)
+		}
+	}
+
+	if c.expectations.satisfied(key) {
+		// Leave the status alone on error. It is recorded by recordFailure once the retries are used up.
+		if active, err = c.managePods(ctx, key, instance, active); err != nil {
+			return err
+		}
+	}
+
+	return c.updateInstance(ctx, instance, active)
+}
+
+// managePods creates or deletes Pods so the number of active Pods equals Spec.Desired. It returns the
+// active Pods which are left.
+func (c *Controller) managePods(ctx context.Context, key string, instance *jinghzhuv1.Jinghzhu, active []*corev1.Pod) ([]*corev1.Pod, error) {
+	diff := len(active) - instance.Spec.Desired
+	if diff == 0 {
+		return active, nil
+	}
+	pods := c.kubeClient.CoreV1().Pods(instance.GetNamespace())
+	errs := make([]error, 0)
+
+	if diff < 0 {
+		diff = -diff
+		c.expectations.expect(key, diff, 0)
+		fmt.Printf("Create %d Pods for Jinghzhu %s\n", diff, key)
+		for i := 0; i < diff; i++ {
+			pod, err := pods.Create(ctx, newPod(instance, &c.podTemplate), metav1.CreateOptions{})
+			if err != nil {
+				c.expectations.observe(key, 1, 0)
+				errs = append(errs, err)
+
+				continue
+			}
+			active = append(active, pod)
+		}
+
+		return active, errors.NewAggregate(errs)
+	}
+
+	// Delete the Pods which are not running yet first.
+	sort.SliceStable(active, func(i, j int) bool {
+		return !isPodRunning(active[i]) && isPodRunning(active[j])
+	})
+	c.expectations.expect(key, 0, diff)
+	fmt.Printf("Delete %d Pods of Jinghzhu %s\n", diff, key)
+	for _, pod := range active[:diff] {
+		err := pods.Delete(ctx, pod.GetName(), metav1.DeleteOptions{})
+		if err != nil {
+			c.expectations.observe(key, 0, 1)
+			if !apierrors.IsNotFound(err) {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	return active[diff:], errors.NewAggregate(errs)
+}
+
+// updateInstance records the active Pods and the progress in Status. It only writes the status
+// subresource, and only if the status has changed. The spec is left to the users, so reconciling never
+// bumps the generation.
+func (c *Controller) updateInstance(ctx context.Context, instance *jinghzhuv1.Jinghzhu, active []*corev1.Pod) error {
+	// Leave it nil when empty, like it comes back from the API server, so no change is detected.
+	var podNames []string
+	running := 0
+	for _, pod := range active {
+		podNames = append(podNames, pod.GetName())
+		if isPodRunning(pod) {
+			running++
+		}
+	}
+	sort.Strings(podNames)
+
+	status := *instance.Status.DeepCopy()
+	status.Replicas = running
+	status.CurrentReplicas = len(active)
+	status.PodNames = podNames
+	status.ObservedGeneration = instance.GetGeneration()
+	status.Selector = selectorFor(instance).String()
+	state := types.StatePending
+	if running == instance.Spec.Desired {
+		state = types.StateRunning
+	}
+	if err := jinghzhuv1.SetState(&status, state, fmt.Sprintf("%d/%d Pods running", running, instance.Spec.Desired)); err != nil {
+		return err
+	}
+	setConditions(&status.Conditions, instance, len(active), running)
+	if reflect.DeepEqual(instance.Status, status) {
+		return nil
+	}
+	updated := instance.DeepCopy()
+	updated.Status = status
+	_, err := c.jinghzhuClient.JinghzhuV1().Jinghzhus(instance.GetNamespace()).UpdateStatus(ctx, updated, metav1.UpdateOptions{})
+
+	return err
+}
+
+// finalize deletes the Pods of a Jinghzhu being deleted and reports the progress in its status. Once
+// all of them are gone, it removes finalizer jinghzhuv1.FinalizerPodCleanup so the API server can
+// remove the Jinghzhu. The Pods are listed from the API server rather than the cache, because a Pod
+// missing from the cache would be left behind.
+func (c *Controller) finalize(ctx context.Context, key string, instance *jinghzhuv1.Jinghzhu) error {
+	if !jinghzhuv1.ContainsFinalizer(instance, jinghzhuv1.FinalizerPodCleanup) {
+		return nil
+	}
+	c.expectations.forget(key)
+	jinghzhus := c.jinghzhuClient.JinghzhuV1().Jinghzhus(instance.GetNamespace())
+	if jinghzhuv1.ContainsFinalizer(instance, metav1.FinalizerOrphanDependents) {
+		// Deleted with propagation policy Orphan. The garbage collector keeps the Pods.
+		fmt.Printf("Jinghzhu %s orphans its Pods, remove finalizer %s\n", key, jinghzhuv1.FinalizerPodCleanup)
+
+		return c.removeFinalizer(ctx, instance)
+	}
+	pods := c.kubeClient.CoreV1().Pods(instance.GetNamespace())
+	podList, err := pods.List(ctx, metav1.ListOptions{LabelSelector: selectorFor(instance).String()})
+	if err != nil {
+		return err
+	}
+	remaining := 0
+	errs := make([]error, 0)
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		// Only delete the Pods owned by the instance, or the orphans it would adopt.
+		if ref := metav1.GetControllerOf(pod); ref != nil && ref.UID != instance.GetUID() {
+			continue
+		}
+		remaining++
+		if pod.GetDeletionTimestamp() != nil {
+			continue
+		}
+		err = pods.Delete(ctx, pod.GetName(), metav1.DeleteOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return errors.NewAggregate(errs)
+	}
+
+	if remaining > 0 {
+		// The Pod deletion events requeue the instance.
+		status := *instance.Status.DeepCopy()
+		if err = jinghzhuv1.SetState(&status, types.StateTerminating, fmt.Sprintf("waiting for %d Pods to be deleted", remaining)); err != nil {
+			return err
+		}
+		if reflect.DeepEqual(instance.Status, status) {
+			return nil
+		}
+		updated := instance.DeepCopy()
+		updated.Status = status
+		_, err = jinghzhus.UpdateStatus(ctx, updated, metav1.UpdateOptions{})
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+
+		return err
+	}
+
+	fmt.Printf("All Pods of Jinghzhu %s are deleted, remove finalizer %s\n", key, jinghzhuv1.FinalizerPodCleanup)
+
+	return c.removeFinalizer(ctx, instance)
+}
+
+// removeFinalizer removes finalizer jinghzhuv1.FinalizerPodCleanup from the Jinghzhu.
+func (c *Controller) removeFinalizer(ctx context.Context, instance *jinghzhuv1.Jinghzhu) error {
+	updated := instance.DeepCopy()
+	jinghzhuv1.RemoveFinalizer(updated, jinghzhuv1.FinalizerPodCleanup)
+	_, err := c.jinghzhuClient.JinghzhuV1().Jinghzhus(instance.GetNamespace()).Update(ctx, updated, metav1.UpdateOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+
+	return err
+}
+
+// setConditions sets the conditions of a Jinghzhu with active Pods out of which running are running.
+func setConditions(conditions *[]jinghzhuv1.JinghzhuCondition, instance *jinghzhuv1.Jinghzhu, active, running int) {
+	generation := instance.GetGeneration()
+	desired := instance.Spec.Desired
+	message := fmt.Sprintf("%d/%d Pods running", running, desired)
+
+	available := jinghzhuv1.JinghzhuCondition{
+		Type:               jinghzhuv1.ConditionAvailable,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: generation,
+		Reason:             "DesiredReached",
+		Message:            message,
+	}
+	if running != desired {
+		available.Status, available.Reason = metav1.ConditionFalse, "PodsNotRunning"
+	}
+	jinghzhuv1.SetStatusCondition(conditions, available)
+
+	progressing := jinghzhuv1.JinghzhuCondition{
+		Type:               jinghzhuv1.ConditionProgressing,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: generation,
+		Message:            message,
+	}
+	switch {
+	case active < desired:
+		progressing.Reason = "ScalingUp"
+	case active > desired:
+		progressing.Reason = "ScalingDown"
+	case running < desired:
+		progressing.Reason = "PodsStarting"
+	default:
+		progressing.Status, progressing.Reason = metav1.ConditionFalse, "DesiredReached"
+	}
+	jinghzhuv1.SetStatusCondition(conditions, progressing)
+
+	jinghzhuv1.SetStatusCondition(conditions, jinghzhuv1.JinghzhuCondition{
+		Type:               jinghzhuv1.ConditionDegraded,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: generation,
+		Reason:             "ReconcileSucceeded",
+	})
+}
@@ -0,0 +1,446 @@
+package controller
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	jinghzhuv1 "github.com/jinghzhu/KubernetesCRD/pkg/crd/jinghzhu/v1"
+	jinghzhuinformers "github.com/jinghzhu/KubernetesCRD/pkg/crd/jinghzhu/v1/apis/informers/externalversions"
+	jinghzhufake "github.com/jinghzhu/KubernetesCRD/pkg/crd/jinghzhu/v1/client/fake"
+	"github.com/jinghzhu/KubernetesCRD/pkg/types"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	apimachinerytypes "k8s.io/apimachinery/pkg/types"
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/client-go/informers"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	testNamespace string = "crd"
+)
+
+// fixture is a Controller on the fake clientsets. Its informers are not started, the objects are put
+// into their caches directly.
+type fixture struct {
+	t          *testing.T
+	kubeClient *kubefake.Clientset
+	client     *jinghzhufake.Client
+	controller *Controller
+
+	jinghzhuIndexer cache.Indexer
+	podIndexer      cache.Indexer
+}
+
+func newFixture(t *testing.T, instances []*jinghzhuv1.Jinghzhu, pods []*corev1.Pod) *fixture {
+	jinghzhuObjects := make([]runtime.Object, 0, len(instances))
+	for _, instance := range instances {
+		jinghzhuObjects = append(jinghzhuObjects, instance)
+	}
+	kubeObjects := make([]runtime.Object, 0, len(pods))
+	for _, pod := range pods {
+		kubeObjects = append(kubeObjects, pod)
+	}
+	f := &fixture{
+		t:          t,
+		kubeClient: kubefake.NewSimpleClientset(kubeObjects...),
+		client:     jinghzhufake.NewSimpleClient(context.Background(), testNamespace, jinghzhuObjects...),
+	}
+
+	// The fake clientset doesn't generate names, so do it like the API server.
+	f.kubeClient.PrependReactor("create", "pods", generateName)
+
+	jinghzhuInformer := jinghzhuinformers.NewSharedInformerFactory(f.client.Clientset, 0).Jinghzhu().V1().Jinghzhus()
+	podInformer := informers.NewSharedInformerFactory(f.kubeClient, 0).Core().V1().Pods()
+	f.controller = NewController(f.kubeClient, f.client.Clientset, jinghzhuInformer, podInformer, Options{})
+	f.jinghzhuIndexer = jinghzhuInformer.Informer().GetIndexer()
+	f.podIndexer = podInformer.Informer().GetIndexer()
+	for _, instance := range instances {
+		if err := f.jinghzhuIndexer.Add(instance); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, pod := range pods {
+		if err := f.podIndexer.Add(pod); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return f
+}
+
+// generateName is a reactor which sets the name of a created object from its GenerateName. It leaves
+// the creation to the next reactor.
+func generateName(action k8stesting.Action) (bool, runtime.Object, error) {
+	obj, err := meta.Accessor(action.(k8stesting.CreateAction).GetObject())
+	if err != nil {
+		return true, nil, err
+	}
+	if obj.GetName() == "" && obj.GetGenerateName() != "" {
+		obj.SetName(obj.GetGenerateName() + utilrand.String(5))
+	}
+
+	return false, nil, nil
+}
+
+// reconcile runs Reconcile for the instance with the given name and fails the test on error.
+func (f *fixture) reconcile(name string) {
+	f.t.Helper()
+	if err := f.controller.Reconcile(context.Background(), testNamespace+"/"+name); err != nil {
+		f.t.Fatalf("Reconcile() error = %v", err)
+	}
+}
+
+// get returns the instance with the given name from the fake clientset.
+func (f *fixture) get(name string) *jinghzhuv1.Jinghzhu {
+	f.t.Helper()
+	instance, err := f.client.Get(name, metav1.GetOptions{})
+	if err != nil {
+		f.t.Fatalf("Get(%s) error = %v", name, err)
+	}
+
+	return instance
+}
+
+// filterActions returns the actions with the given verb on the given resource. An empty subresource
+// only matches the actions on the main resource.
+func filterActions(actions []k8stesting.Action, verb, resource, subresource string) []k8stesting.Action {
+	filtered := make([]k8stesting.Action, 0)
+	for _, action := range actions {
+		if action.Matches(verb, resource) && action.GetSubresource() == subresource {
+			filtered = append(filtered, action)
+		}
+	}
+
+	return filtered
+}
+
+func newInstance(name string, desired int) *jinghzhuv1.Jinghzhu {
+	return &jinghzhuv1.Jinghzhu{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: jinghzhuv1.SchemeGroupVersion.String(),
+			Kind:       jinghzhuv1.Kind,
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       name,
+			Namespace:  testNamespace,
+			UID:        apimachinerytypes.UID(name + "-uid"),
+			Generation: 3,
+			Finalizers: []string{jinghzhuv1.FinalizerPodCleanup},
+		},
+		Spec: jinghzhuv1.JinghzhuSpec{
+			Desired: desired,
+		},
+	}
+}
+
+func newTestPod(instance *jinghzhuv1.Jinghzhu, name string, phase corev1.PodPhase) *corev1.Pod {
+	pod := newPod(instance, DefaultPodTemplate())
+	pod.Name = name
+	pod.Status.Phase = phase
+
+	return pod
+}
+
+func TestReconcileCreatesPods(t *testing.T) {
+	instance := newInstance("scale-up", 2)
+	f := newFixture(t, []*jinghzhuv1.Jinghzhu{instance}, nil)
+
+	f.reconcile(instance.Name)
+
+	creates := filterActions(f.kubeClient.Actions(), "create", "pods", "")
+	if len(creates) != 2 {
+		t.Fatalf("got %d Pod creations, want 2", len(creates))
+	}
+	for _, action := range creates {
+		pod := action.(k8stesting.CreateAction).GetObject().(*corev1.Pod)
+		if ref := metav1.GetControllerOf(pod); ref == nil || ref.UID != instance.UID {
+			t.Errorf("Pod %s is controlled by %v, want %s", pod.Name, ref, instance.UID)
+		}
+		if pod.Labels[InstanceLabel] != instance.Name {
+			t.Errorf("Pod %s has label %s=%q, want %q", pod.Name, InstanceLabel, pod.Labels[InstanceLabel], instance.Name)
+		}
+		if pod.GenerateName != instance.Name+"-" {
+			t.Errorf("Pod %s has generateName %q, want %s-", pod.Name, pod.GenerateName, instance.Name)
+		}
+	}
+
+	got := f.get(instance.Name)
+	if got.Status.CurrentReplicas != 2 || got.Status.Replicas != 0 || len(got.Status.PodNames) != 2 {
+		t.Errorf("status has %d current and %d running Pods %v, want 2 current and 0 running",
+			got.Status.CurrentReplicas, got.Status.Replicas, got.Status.PodNames)
+	}
+	if len(got.Status.PodNames) == 2 && (got.Status.PodNames[0] == got.Status.PodNames[1] ||
+		!strings.HasPrefix(got.Status.PodNames[0], instance.Name+"-")) {
+		t.Errorf("podNames = %v, want the names generated by the server", got.Status.PodNames)
+	}
+	if got.Status.ObservedGeneration != instance.Generation {
+		t.Errorf("observedGeneration = %d, want %d", got.Status.ObservedGeneration, instance.Generation)
+	}
+	if got.Status.State != types.StatePending {
+		t.Errorf("state = %q, want %q", got.Status.State, types.StatePending)
+	}
+	if got.Status.Selector != InstanceLabel+"="+instance.Name {
+		t.Errorf("selector = %q, want %s=%s", got.Status.Selector, InstanceLabel, instance.Name)
+	}
+	if updates := filterActions(f.client.Clientset.Actions(), "update", jinghzhuv1.Plural, ""); len(updates) != 0 {
+		t.Errorf("got %d updates of the instance, want the status subresource only", len(updates))
+	}
+	if updates := filterActions(f.client.Clientset.Actions(), "update", jinghzhuv1.Plural, "status"); len(updates) != 1 {
+		t.Errorf("got %d status updates, want 1", len(updates))
+	}
+}
+
+func TestReconcileRunning(t *testing.T) {
+	instance := newInstance("running", 1)
+	pod := newTestPod(instance, "running-a", corev1.PodRunning)
+	f := newFixture(t, []*jinghzhuv1.Jinghzhu{instance}, []*corev1.Pod{pod})
+
+	f.reconcile(instance.Name)
+
+	if actions := f.kubeClient.Actions(); len(actions) != 0 {
+		t.Errorf("got Pod actions %v, want none", actions)
+	}
+	got := f.get(instance.Name)
+	if got.Status.State != types.StateRunning {
+		t.Errorf("state = %q, want %q", got.Status.State, types.StateRunning)
+	}
+	if got.Status.Replicas != 1 || got.Status.CurrentReplicas != 1 {
+		t.Errorf("status has %d current and %d running Pods, want 1 and 1", got.Status.CurrentReplicas, got.Status.Replicas)
+	}
+	if got.Spec.Current != 0 || len(got.Spec.PodList) != 0 {
+		t.Errorf("spec = %+v, the controller must not write the observed state into it", got.Spec)
+	}
+	available := jinghzhuv1.FindStatusCondition(got.Status.Conditions, jinghzhuv1.ConditionAvailable)
+	if available == nil || available.Status != metav1.ConditionTrue {
+		t.Errorf("condition Available = %+v, want True", available)
+	}
+}
+
+func TestReconcileUnchangedStatus(t *testing.T) {
+	instance := newInstance("unchanged", 1)
+	pod := newTestPod(instance, "unchanged-a", corev1.PodRunning)
+	f := newFixture(t, []*jinghzhuv1.Jinghzhu{instance}, []*corev1.Pod{pod})
+	f.reconcile(instance.Name)
+
+	// Feed the written status back into the cache, like the informer would.
+	if err := f.jinghzhuIndexer.Update(f.get(instance.Name)); err != nil {
+		t.Fatal(err)
+	}
+	f.client.Clientset.ClearActions()
+	f.reconcile(instance.Name)
+
+	if updates := filterActions(f.client.Clientset.Actions(), "update", jinghzhuv1.Plural, "status"); len(updates) != 0 {
+		t.Errorf("got %d status updates, want none when nothing has changed", len(updates))
+	}
+}
+
+func TestReconcileDeletesPods(t *testing.T) {
+	instance := newInstance("scale-down", 1)
+	running := newTestPod(instance, "scale-down-running", corev1.PodRunning)
+	pending := newTestPod(instance, "scale-down-pending", corev1.PodPending)
+	f := newFixture(t, []*jinghzhuv1.Jinghzhu{instance}, []*corev1.Pod{running, pending})
+
+	f.reconcile(instance.Name)
+
+	deletes := filterActions(f.kubeClient.Actions(), "delete", "pods", "")
+	if len(deletes) != 1 {
+		t.Fatalf("got %d Pod deletions, want 1", len(deletes))
+	}
+	if name := deletes[0].(k8stesting.DeleteAction).GetName(); name != pending.Name {
+		t.Errorf("deleted Pod %s, want the Pod which is not running yet %s", name, pending.Name)
+	}
+	got := f.get(instance.Name)
+	if !reflect.DeepEqual(got.Status.PodNames, []string{running.Name}) {
+		t.Errorf("podNames = %v, want [%s]", got.Status.PodNames, running.Name)
+	}
+}
+
+func TestReconcileAdoptsOrphans(t *testing.T) {
+	instance := newInstance("adopt", 1)
+	orphan := newTestPod(instance, "adopt-orphan", corev1.PodRunning)
+	orphan.OwnerReferences = nil
+	f := newFixture(t, []*jinghzhuv1.Jinghzhu{instance}, []*corev1.Pod{orphan})
+
+	f.reconcile(instance.Name)
+
+	if patches := filterActions(f.kubeClient.Actions(), "patch", "pods", ""); len(patches) != 1 {
+		t.Fatalf("got %d Pod patches, want 1", len(patches))
+	}
+	pod, err := f.kubeClient.CoreV1().Pods(testNamespace).Get(context.Background(), orphan.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ref := metav1.GetControllerOf(pod); ref == nil || ref.UID != instance.UID {
+		t.Errorf("Pod is controlled by %v, want %s", ref, instance.UID)
+	}
+	if creates := filterActions(f.kubeClient.Actions(), "create", "pods", ""); len(creates) != 0 {
+		t.Errorf("got %d Pod creations, want the orphan to count", len(creates))
+	}
+}
+
+func TestReconcileAddsFinalizer(t *testing.T) {
+	instance := newInstance("finalizer", 0)
+	instance.Finalizers = nil
+	f := newFixture(t, []*jinghzhuv1.Jinghzhu{instance}, nil)
+
+	f.reconcile(instance.Name)
+
+	if updates := filterActions(f.client.Clientset.Actions(), "update", jinghzhuv1.Plural, ""); len(updates) != 1 {
+		t.Fatalf("got %d updates of the instance, want 1", len(updates))
+	}
+	if got := f.get(instance.Name); !jinghzhuv1.ContainsFinalizer(got, jinghzhuv1.FinalizerPodCleanup) {
+		t.Errorf("finalizers = %v, want %s", got.Finalizers, jinghzhuv1.FinalizerPodCleanup)
+	}
+}
+
+func TestReconcileFinalState(t *testing.T) {
+	instance := newInstance("final", 2)
+	instance.Status.State = types.StateSucceeded
+	f := newFixture(t, []*jinghzhuv1.Jinghzhu{instance}, nil)
+
+	f.reconcile(instance.Name)
+
+	if actions := f.kubeClient.Actions(); len(actions) != 0 {
+		t.Errorf("got Pod actions %v, want none", actions)
+	}
+	if actions := f.client.Clientset.Actions(); len(actions) != 0 {
+		t.Errorf("got Jinghzhu actions %v, want none", actions)
+	}
+}
+
+func TestReconcileNotFound(t *testing.T) {
+	f := newFixture(t, nil, nil)
+
+	f.reconcile("missing")
+
+	if actions := f.kubeClient.Actions(); len(actions) != 0 {
+		t.Errorf("got Pod actions %v, want none", actions)
+	}
+}
+
+func TestReconcileFinalize(t *testing.T) {
+	now := metav1.Now()
+	instance := newInstance("deleted", 1)
+	instance.DeletionTimestamp = &now
+	pod := newTestPod(instance, "deleted-a", corev1.PodRunning)
+	f := newFixture(t, []*jinghzhuv1.Jinghzhu{instance}, []*corev1.Pod{pod})
+
+	f.reconcile(instance.Name)
+
+	if deletes := filterActions(f.kubeClient.Actions(), "delete", "pods", ""); len(deletes) != 1 {
+		t.Fatalf("got %d Pod deletions, want 1", len(deletes))
+	}
+	got := f.get(instance.Name)
+	if got.Status.State != types.StateTerminating {
+		t.Errorf("state = %q, want %q", got.Status.State, types.StateTerminating)
+	}
+	if !jinghzhuv1.ContainsFinalizer(got, jinghzhuv1.FinalizerPodCleanup) {
+		t.Fatalf("finalizer removed while the Pods are still there")
+	}
+
+	// The Pods are gone now.
+	if err := f.jinghzhuIndexer.Update(got); err != nil {
+		t.Fatal(err)
+	}
+	f.reconcile(instance.Name)
+
+	if got = f.get(instance.Name); jinghzhuv1.ContainsFinalizer(got, jinghzhuv1.FinalizerPodCleanup) {
+		t.Errorf("finalizers = %v, want %s removed", got.Finalizers, jinghzhuv1.FinalizerPodCleanup)
+	}
+}
+
+func TestSetConditions(t *testing.T) {
+	type want struct {
+		status metav1.ConditionStatus
+		reason string
+	}
+	tests := []struct {
+		name            string
+		desired         int
+		active, running int
+		available       want
+		progressing     want
+	}{
+		{"desired reached", 2, 2, 2, want{metav1.ConditionTrue, "DesiredReached"}, want{metav1.ConditionFalse, "DesiredReached"}},
+		{"scaling up", 3, 1, 1, want{metav1.ConditionFalse, "PodsNotRunning"}, want{metav1.ConditionTrue, "ScalingUp"}},
+		{"scaling down", 1, 3, 2, want{metav1.ConditionFalse, "PodsNotRunning"}, want{metav1.ConditionTrue, "ScalingDown"}},
+		{"scaling down running", 1, 2, 1, want{metav1.ConditionTrue, "DesiredReached"}, want{metav1.ConditionTrue, "ScalingDown"}},
+		{"pods starting", 2, 2, 1, want{metav1.ConditionFalse, "PodsNotRunning"}, want{metav1.ConditionTrue, "PodsStarting"}},
+		{"scaled to zero", 0, 0, 0, want{metav1.ConditionTrue, "DesiredReached"}, want{metav1.ConditionFalse, "DesiredReached"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			instance := newInstance("conditions", tt.desired)
+			// A reconcile which succeeds clears Degraded.
+			conditions := []jinghzhuv1.JinghzhuCondition{{
+				Type:   jinghzhuv1.ConditionDegraded,
+				Status: metav1.ConditionTrue,
+				Reason: "ReconcileFailed",
+			}}
+
+			setConditions(&conditions, instance, tt.active, tt.running)
+			for conditionType, expected := range map[string]want{
+				jinghzhuv1.ConditionAvailable:   tt.available,
+				jinghzhuv1.ConditionProgressing: tt.progressing,
+				jinghzhuv1.ConditionDegraded:    {metav1.ConditionFalse, "ReconcileSucceeded"},
+			} {
+				condition := jinghzhuv1.FindStatusCondition(conditions, conditionType)
+				if condition == nil {
+					t.Errorf("condition %s isn't set", conditionType)
+
+					continue
+				}
+				if condition.Status != expected.status || condition.Reason != expected.reason {
+					t.Errorf("%s is %s %s, want %s %s", conditionType, condition.Status, condition.Reason, expected.status, expected.reason)
+				}
+				if condition.ObservedGeneration != instance.Generation {
+					t.Errorf("%s observedGeneration = %d, want %d", conditionType, condition.ObservedGeneration, instance.Generation)
+				}
+			}
+			if len(conditions) != 3 {
+				t.Errorf("got %d conditions, want 3", len(conditions))
+			}
+		})
+	}
+}
+
+func TestSetConditionsKeepsTransitionTime(t *testing.T) {
+	instance := newInstance("conditions", 2)
+	conditions := make([]jinghzhuv1.JinghzhuCondition, 0)
+	setConditions(&conditions, instance, 2, 1)
+	before := metav1.NewTime(time.Now().Add(-time.Hour).Truncate(time.Second))
+	for i := range conditions {
+		conditions[i].LastTransitionTime = before
+	}
+	transitioned := func(conditionType string) bool {
+		return !jinghzhuv1.FindStatusCondition(conditions, conditionType).LastTransitionTime.Equal(&before)
+	}
+
+	// Progressing stays True for another reason, and Available stays False.
+	setConditions(&conditions, instance, 3, 1)
+	if progressing := jinghzhuv1.FindStatusCondition(conditions, jinghzhuv1.ConditionProgressing); progressing.Reason != "ScalingDown" {
+		t.Errorf("Progressing = %+v, want reason ScalingDown", progressing)
+	}
+	for _, conditionType := range []string{jinghzhuv1.ConditionAvailable, jinghzhuv1.ConditionProgressing, jinghzhuv1.ConditionDegraded} {
+		if transitioned(conditionType) {
+			t.Errorf("%s moved its lastTransitionTime without a status change", conditionType)
+		}
+	}
+
+	// Available flips to True and Progressing to False.
+	setConditions(&conditions, instance, 2, 2)
+	if !transitioned(jinghzhuv1.ConditionAvailable) || !transitioned(jinghzhuv1.ConditionProgressing) {
+		t.Errorf("conditions = %+v, want the lastTransitionTime of the flipped ones moved", conditions)
+	}
+	if transitioned(jinghzhuv1.ConditionDegraded) {
+		t.Errorf("Degraded moved its lastTransitionTime without a status change")
+	}
+}
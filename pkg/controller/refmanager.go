@@ -0,0 +1,143 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	jinghzhuv1 "github.com/jinghzhu/KubernetesCRD/pkg/crd/jinghzhu/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	apimachinerytypes "k8s.io/apimachinery/pkg/types"
+	errors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+// controllerKind is the kind put in the OwnerReferences of the Pods.
+var controllerKind = jinghzhuv1.SchemeGroupVersion.WithKind(jinghzhuv1.Kind)
+
+// podRefManager claims Pods for a Jinghzhu the way ReplicaSet's ControllerRefManager does:
+// * Pods controlled by the Jinghzhu and matching its selector are kept.
+// * Pods controlled by the Jinghzhu but not matching its selector anymore are released.
+// * Orphan Pods matching its selector are adopted.
+// * Pods controlled by anything else are ignored.
+type podRefManager struct {
+	controller *Controller
+	instance   *jinghzhuv1.Jinghzhu
+	selector   labels.Selector
+
+	// canAdoptErr caches the result of the first canAdopt call.
+	canAdoptChecked bool
+	canAdoptErr     error
+}
+
+func newPodRefManager(c *Controller, instance *jinghzhuv1.Jinghzhu) *podRefManager {
+	return &podRefManager{
+		controller: c,
+		instance:   instance,
+		selector:   selectorFor(instance),
+	}
+}
+
+// claimPods adopts and releases Pods as needed, and returns the ones the Jinghzhu owns afterwards.
+func (m *podRefManager) claimPods(ctx context.Context, pods []*corev1.Pod) ([]*corev1.Pod, error) {
+	claimed := make([]*corev1.Pod, 0, len(pods))
+	errs := make([]error, 0)
+	for _, pod := range pods {
+		ok, err := m.claimPod(ctx, pod)
+		if err != nil {
+			errs = append(errs, err)
+
+			continue
+		}
+		if ok {
+			claimed = append(claimed, pod)
+		}
+	}
+
+	return claimed, errors.NewAggregate(errs)
+}
+
+func (m *podRefManager) claimPod(ctx context.Context, pod *corev1.Pod) (bool, error) {
+	match := m.selector.Matches(labels.Set(pod.GetLabels()))
+	if ref := metav1.GetControllerOf(pod); ref != nil {
+		if ref.UID != m.instance.GetUID() {
+			return false, nil
+		}
+		if match {
+			return true, nil
+		}
+		// Don't touch the Pods of a Jinghzhu being deleted.
+		if m.instance.GetDeletionTimestamp() != nil {
+			return false, nil
+		}
+		err := m.releasePod(ctx, pod)
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	if !match || m.instance.GetDeletionTimestamp() != nil || pod.GetDeletionTimestamp() != nil {
+		return false, nil
+	}
+	if err := m.canAdopt(ctx); err != nil {
+		return false, err
+	}
+	err := m.adoptPod(ctx, pod)
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+// canAdopt makes sure the Jinghzhu still exists and isn't being deleted. The cached instance may be
+// stale, and adopting Pods for a deleted Jinghzhu would let the garbage collector delete them.
+func (m *podRefManager) canAdopt(ctx context.Context) error {
+	if m.canAdoptChecked {
+		return m.canAdoptErr
+	}
+	m.canAdoptChecked = true
+	fresh, err := m.controller.jinghzhuClient.JinghzhuV1().Jinghzhus(m.instance.GetNamespace()).Get(ctx, m.instance.GetName(), metav1.GetOptions{})
+	switch {
+	case err != nil:
+		m.canAdoptErr = err
+	case fresh.GetUID() != m.instance.GetUID():
+		m.canAdoptErr = fmt.Errorf("original Jinghzhu %s/%s is gone, got uid %v, wanted %v",
+			m.instance.GetNamespace(), m.instance.GetName(), fresh.GetUID(), m.instance.GetUID())
+	case fresh.GetDeletionTimestamp() != nil:
+		m.canAdoptErr = fmt.Errorf("Jinghzhu %s/%s is being deleted", m.instance.GetNamespace(), m.instance.GetName())
+	}
+
+	return m.canAdoptErr
+}
+
+// adoptPod sets the Jinghzhu as the controller of the Pod. The uid in the patch makes it fail if the
+// Pod has been replaced by another one with the same name.
+func (m *podRefManager) adoptPod(ctx context.Context, pod *corev1.Pod) error {
+	fmt.Printf("Jinghzhu %s/%s adopts Pod %s\n", m.instance.GetNamespace(), m.instance.GetName(), pod.GetName())
+	ref := metav1.NewControllerRef(m.instance, controllerKind)
+	patch := fmt.Sprintf(`{"metadata":{"ownerReferences":[{"apiVersion":%q,"kind":%q,"name":%q,"uid":%q,"controller":true,"blockOwnerDeletion":true}],"uid":%q}}`,
+		ref.APIVersion, ref.Kind, ref.Name, ref.UID, pod.GetUID())
+	_, err := m.controller.kubeClient.CoreV1().Pods(pod.GetNamespace()).Patch(ctx, pod.GetName(),
+		apimachinerytypes.StrategicMergePatchType, []byte(patch), metav1.PatchOptions{})
+
+	return err
+}
+
+// releasePod removes the OwnerReference of the Jinghzhu from the Pod.
+func (m *podRefManager) releasePod(ctx context.Context, pod *corev1.Pod) error {
+	fmt.Printf("Jinghzhu %s/%s releases Pod %s\n", m.instance.GetNamespace(), m.instance.GetName(), pod.GetName())
+	patch := fmt.Sprintf(`{"metadata":{"ownerReferences":[{"$patch":"delete","uid":%q}],"uid":%q}}`,
+		m.instance.GetUID(), pod.GetUID())
+	_, err := m.controller.kubeClient.CoreV1().Pods(pod.GetNamespace()).Patch(ctx, pod.GetName(),
+		apimachinerytypes.StrategicMergePatchType, []byte(patch), metav1.PatchOptions{})
+	if apierrors.IsInvalid(err) {
+		// The Pod is being deleted, so the OwnerReference can't be changed anymore.
+		return nil
+	}
+
+	return err
+}
@@ -0,0 +1,39 @@
+package v1alpha
+
+import (
+	"reflect"
+
+	crdexample "github.com/jinghzhu/KubernetesCRD/pkg/crd/example"
+	"github.com/jinghzhu/KubernetesCRD/pkg/crd/schema"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// NewCustomResourceDefinition returns the apiextensions.k8s.io/v1 definition of CRD Example. Its
+// validation schema is generated from the Example type.
+func NewCustomResourceDefinition() *apiextensionsv1.CustomResourceDefinition {
+	return &apiextensionsv1.CustomResourceDefinition{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: CRDName,
+		},
+		Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+			Group: crdexample.GroupName,
+			Scope: apiextensionsv1.NamespaceScoped,
+			Names: apiextensionsv1.CustomResourceDefinitionNames{
+				Plural:   Plural,
+				Singular: Singular,
+				Kind:     reflect.TypeOf(Example{}).Name(),
+			},
+			Versions: []apiextensionsv1.CustomResourceDefinitionVersion{
+				{
+					Name:    SchemeGroupVersion.Version,
+					Served:  true,
+					Storage: true,
+					Schema: &apiextensionsv1.CustomResourceValidation{
+						OpenAPIV3Schema: schema.Generate(Example{}),
+					},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,110 @@
+// Package conversion converts Jinghzhu between v1 and v2, both in Go and as the conversion webhook of
+// the CRD, so instances stored in one version can be served in the other.
+package conversion
+
+import (
+	jinghzhuv1 "github.com/jinghzhu/KubernetesCRD/pkg/crd/jinghzhu/v1"
+	jinghzhuv2 "github.com/jinghzhu/KubernetesCRD/pkg/crd/jinghzhu/v2"
+)
+
+// V1ToV2 converts a v1 Jinghzhu to v2. The status fields map one to one, Status.Replicas of v1 being
+// Status.ReadyReplicas of v2. The deprecated Spec.Current and Spec.PodList are dropped, because v2 only
+// keeps the observed state in the status. The instances written before the observed state moved to the
+// status only have it in them though, so they are used while Status.Replicas and Status.PodNames are
+// empty and the controller hasn't set Status.ObservedGeneration yet.
+func V1ToV2(in *jinghzhuv1.Jinghzhu) (*jinghzhuv2.Jinghzhu, error) {
+	out := &jinghzhuv2.Jinghzhu{}
+	out.APIVersion = jinghzhuv2.SchemeGroupVersion.String()
+	out.Kind = jinghzhuv2.Kind
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec.Desired = in.Spec.Desired
+	out.Status = jinghzhuv2.JinghzhuStatus{
+		State:              in.Status.State,
+		Message:            in.Status.Message,
+		CurrentReplicas:    in.Status.CurrentReplicas,
+		ReadyReplicas:      in.Status.Replicas,
+		ObservedGeneration: in.Status.ObservedGeneration,
+		Selector:           in.Status.Selector,
+	}
+	if in.Status.PodNames != nil {
+		out.Status.PodNames = append([]string(nil), in.Status.PodNames...)
+	}
+	if in.Status.ObservedGeneration == 0 {
+		// Not observed since the move, the spec is all there is.
+		if out.Status.ReadyReplicas == 0 {
+			out.Status.ReadyReplicas = in.Spec.Current
+		}
+		if len(out.Status.PodNames) == 0 && len(in.Spec.PodList) > 0 {
+			out.Status.PodNames = append([]string(nil), in.Spec.PodList...)
+			if out.Status.CurrentReplicas == 0 {
+				out.Status.CurrentReplicas = len(in.Spec.PodList)
+			}
+		}
+	}
+	for _, condition := range in.Status.Conditions {
+		out.Status.Conditions = append(out.Status.Conditions, jinghzhuv2.JinghzhuCondition{
+			Type:               condition.Type,
+			Status:             condition.Status,
+			ObservedGeneration: condition.ObservedGeneration,
+			LastTransitionTime: condition.LastTransitionTime,
+			Reason:             condition.Reason,
+			Message:            condition.Message,
+		})
+	}
+	for _, transition := range in.Status.History {
+		out.Status.History = append(out.Status.History, jinghzhuv2.StateTransition{
+			From:    transition.From,
+			To:      transition.To,
+			Time:    transition.Time,
+			Message: transition.Message,
+		})
+	}
+
+	return out, nil
+}
+
+// V2ToV1 converts a v2 Jinghzhu to v1. It is the reverse of V1ToV2, so a v2 object survives a round
+// trip through v1. The deprecated Spec.Current and Spec.PodList are filled from the status for the v1
+// clients which still read them.
+func V2ToV1(in *jinghzhuv2.Jinghzhu) (*jinghzhuv1.Jinghzhu, error) {
+	out := &jinghzhuv1.Jinghzhu{}
+	out.APIVersion = jinghzhuv1.SchemeGroupVersion.String()
+	out.Kind = jinghzhuv1.Kind
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = jinghzhuv1.JinghzhuSpec{
+		Desired: in.Spec.Desired,
+		Current: in.Status.ReadyReplicas,
+	}
+	out.Status = jinghzhuv1.JinghzhuStatus{
+		State:              in.Status.State,
+		Message:            in.Status.Message,
+		Replicas:           in.Status.ReadyReplicas,
+		CurrentReplicas:    in.Status.CurrentReplicas,
+		ObservedGeneration: in.Status.ObservedGeneration,
+		Selector:           in.Status.Selector,
+	}
+	if in.Status.PodNames != nil {
+		out.Spec.PodList = append([]string(nil), in.Status.PodNames...)
+		out.Status.PodNames = append([]string(nil), in.Status.PodNames...)
+	}
+	for _, condition := range in.Status.Conditions {
+		out.Status.Conditions = append(out.Status.Conditions, jinghzhuv1.JinghzhuCondition{
+			Type:               condition.Type,
+			Status:             condition.Status,
+			ObservedGeneration: condition.ObservedGeneration,
+			LastTransitionTime: condition.LastTransitionTime,
+			Reason:             condition.Reason,
+			Message:            condition.Message,
+		})
+	}
+	for _, transition := range in.Status.History {
+		out.Status.History = append(out.Status.History, jinghzhuv1.StateTransition{
+			From:    transition.From,
+			To:      transition.To,
+			Time:    transition.Time,
+			Message: transition.Message,
+		})
+	}
+
+	return out, nil
+}
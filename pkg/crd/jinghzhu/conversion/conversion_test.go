@@ -0,0 +1,200 @@
+package conversion
+
+import (
+	"reflect"
+	"testing"
+
+	jinghzhuv1 "github.com/jinghzhu/KubernetesCRD/pkg/crd/jinghzhu/v1"
+	jinghzhuv2 "github.com/jinghzhu/KubernetesCRD/pkg/crd/jinghzhu/v2"
+	"github.com/jinghzhu/KubernetesCRD/pkg/types"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newV2(status jinghzhuv2.JinghzhuStatus) *jinghzhuv2.Jinghzhu {
+	return &jinghzhuv2.Jinghzhu{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: jinghzhuv2.SchemeGroupVersion.String(),
+			Kind:       jinghzhuv2.Kind,
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "example",
+			Namespace:  "crd",
+			Generation: 2,
+			Labels:     map[string]string{"app": "example"},
+		},
+		Spec:   jinghzhuv2.JinghzhuSpec{Desired: 3},
+		Status: status,
+	}
+}
+
+func TestV2RoundTrip(t *testing.T) {
+	now := metav1.Now()
+	tests := []struct {
+		name string
+		in   *jinghzhuv2.Jinghzhu
+	}{
+		{"empty status", newV2(jinghzhuv2.JinghzhuStatus{})},
+		{"full status", newV2(jinghzhuv2.JinghzhuStatus{
+			State:              types.StateRunning,
+			Message:            "2/3 Pods running",
+			CurrentReplicas:    3,
+			ReadyReplicas:      2,
+			PodNames:           []string{"example-a", "example-b", "example-c"},
+			ObservedGeneration: 2,
+			Selector:           "jinghzhu.io/instance=example",
+			Conditions: []jinghzhuv2.JinghzhuCondition{{
+				Type:               "Available",
+				Status:             metav1.ConditionFalse,
+				ObservedGeneration: 2,
+				LastTransitionTime: now,
+				Reason:             "PodsNotRunning",
+				Message:            "2/3 Pods running",
+			}},
+			History: []jinghzhuv2.StateTransition{
+				{From: "", To: types.StatePending, Time: now, Message: "created"},
+				{From: types.StatePending, To: types.StateRunning, Time: now, Message: "2/3 Pods running"},
+			},
+		})},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v1, err := V2ToV1(tt.in)
+			if err != nil {
+				t.Fatalf("V2ToV1() error = %v", err)
+			}
+			if v1.APIVersion != jinghzhuv1.SchemeGroupVersion.String() || v1.Kind != jinghzhuv1.Kind {
+				t.Errorf("V2ToV1() is %s %s", v1.APIVersion, v1.Kind)
+			}
+			if v1.Spec.Current != tt.in.Status.ReadyReplicas || !reflect.DeepEqual(v1.Spec.PodList, tt.in.Status.PodNames) {
+				t.Errorf("V2ToV1() spec = %+v, want the deprecated fields filled from the status", v1.Spec)
+			}
+			got, err := V1ToV2(v1)
+			if err != nil {
+				t.Fatalf("V1ToV2() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.in) {
+				t.Errorf("round trip = %+v, want %+v", got, tt.in)
+			}
+		})
+	}
+}
+
+func TestV1ToV2(t *testing.T) {
+	in := &jinghzhuv1.Jinghzhu{
+		ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "crd"},
+		Spec: jinghzhuv1.JinghzhuSpec{
+			Desired: 2,
+			// The deprecated fields are dropped, the status is the source of truth.
+			Current: 5,
+			PodList: []string{"stale"},
+		},
+		Status: jinghzhuv1.JinghzhuStatus{
+			State:              types.StatePending,
+			Replicas:           1,
+			CurrentReplicas:    2,
+			PodNames:           []string{"example-a", "example-b"},
+			ObservedGeneration: 1,
+		},
+	}
+	want := jinghzhuv2.JinghzhuStatus{
+		State:              types.StatePending,
+		ReadyReplicas:      1,
+		CurrentReplicas:    2,
+		PodNames:           []string{"example-a", "example-b"},
+		ObservedGeneration: 1,
+	}
+
+	out, err := V1ToV2(in)
+	if err != nil {
+		t.Fatalf("V1ToV2() error = %v", err)
+	}
+	if out.Spec.Desired != 2 {
+		t.Errorf("desired = %d, want 2", out.Spec.Desired)
+	}
+	if !reflect.DeepEqual(out.Status, want) {
+		t.Errorf("status = %+v, want %+v", out.Status, want)
+	}
+
+	back, err := V2ToV1(out)
+	if err != nil {
+		t.Fatalf("V2ToV1() error = %v", err)
+	}
+	if !reflect.DeepEqual(back.Status, in.Status) {
+		t.Errorf("round trip status = %+v, want %+v", back.Status, in.Status)
+	}
+	if back.Spec.Current != in.Status.Replicas || !reflect.DeepEqual(back.Spec.PodList, in.Status.PodNames) {
+		t.Errorf("round trip spec = %+v, want the deprecated fields to follow the status", back.Spec)
+	}
+}
+
+func TestV1ToV2Legacy(t *testing.T) {
+	tests := []struct {
+		name string
+		in   jinghzhuv1.Jinghzhu
+		want jinghzhuv2.JinghzhuStatus
+	}{
+		{
+			name: "observed state in the spec only",
+			in: jinghzhuv1.Jinghzhu{
+				Spec:   jinghzhuv1.JinghzhuSpec{Desired: 2, Current: 2, PodList: []string{"example-a", "example-b"}},
+				Status: jinghzhuv1.JinghzhuStatus{State: types.StateRunning},
+			},
+			want: jinghzhuv2.JinghzhuStatus{
+				State:           types.StateRunning,
+				ReadyReplicas:   2,
+				CurrentReplicas: 2,
+				PodNames:        []string{"example-a", "example-b"},
+			},
+		},
+		{
+			name: "pods not running yet",
+			in: jinghzhuv1.Jinghzhu{
+				Spec:   jinghzhuv1.JinghzhuSpec{Desired: 1, PodList: []string{"example-a"}},
+				Status: jinghzhuv1.JinghzhuStatus{State: types.StatePending},
+			},
+			want: jinghzhuv2.JinghzhuStatus{
+				State:           types.StatePending,
+				CurrentReplicas: 1,
+				PodNames:        []string{"example-a"},
+			},
+		},
+		{
+			name: "status wins over the spec",
+			in: jinghzhuv1.Jinghzhu{
+				Spec:   jinghzhuv1.JinghzhuSpec{Desired: 1, Current: 3, PodList: []string{"stale"}},
+				Status: jinghzhuv1.JinghzhuStatus{Replicas: 1, CurrentReplicas: 1, PodNames: []string{"example-a"}},
+			},
+			want: jinghzhuv2.JinghzhuStatus{
+				ReadyReplicas:   1,
+				CurrentReplicas: 1,
+				PodNames:        []string{"example-a"},
+			},
+		},
+		{
+			name: "stale spec after the controller observed",
+			in: jinghzhuv1.Jinghzhu{
+				Spec:   jinghzhuv1.JinghzhuSpec{Desired: 0, Current: 2, PodList: []string{"stale"}},
+				Status: jinghzhuv1.JinghzhuStatus{State: types.StateRunning, ObservedGeneration: 2},
+			},
+			want: jinghzhuv2.JinghzhuStatus{State: types.StateRunning, ObservedGeneration: 2},
+		},
+		{
+			name: "nothing observed",
+			in: jinghzhuv1.Jinghzhu{
+				Spec: jinghzhuv1.JinghzhuSpec{Desired: 1, PodList: []string{}},
+			},
+			want: jinghzhuv2.JinghzhuStatus{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := V1ToV2(&tt.in)
+			if err != nil {
+				t.Fatalf("V1ToV2() error = %v", err)
+			}
+			if !reflect.DeepEqual(out.Status, tt.want) {
+				t.Errorf("status = %+v, want %+v", out.Status, tt.want)
+			}
+		})
+	}
+}
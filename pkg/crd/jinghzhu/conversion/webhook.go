@@ -0,0 +1,116 @@
+package conversion
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	jinghzhuv1 "github.com/jinghzhu/KubernetesCRD/pkg/crd/jinghzhu/v1"
+	jinghzhuv2 "github.com/jinghzhu/KubernetesCRD/pkg/crd/jinghzhu/v2"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const (
+	// WebhookPath is the path the conversion webhook is served at.
+	WebhookPath string = "/convert"
+)
+
+// Convert converts one serialized Jinghzhu to desiredAPIVersion, e.g. jinghzhu.io/v2.
+func Convert(raw []byte, desiredAPIVersion string) ([]byte, error) {
+	var typeMeta metav1.TypeMeta
+	if err := json.Unmarshal(raw, &typeMeta); err != nil {
+		return nil, err
+	}
+	if typeMeta.APIVersion == desiredAPIVersion {
+		return raw, nil
+	}
+
+	var out interface{}
+	switch {
+	case typeMeta.APIVersion == jinghzhuv1.SchemeGroupVersion.String() && desiredAPIVersion == jinghzhuv2.SchemeGroupVersion.String():
+		in := &jinghzhuv1.Jinghzhu{}
+		if err := json.Unmarshal(raw, in); err != nil {
+			return nil, err
+		}
+		converted, err := V1ToV2(in)
+		if err != nil {
+			return nil, err
+		}
+		out = converted
+	case typeMeta.APIVersion == jinghzhuv2.SchemeGroupVersion.String() && desiredAPIVersion == jinghzhuv1.SchemeGroupVersion.String():
+		in := &jinghzhuv2.Jinghzhu{}
+		if err := json.Unmarshal(raw, in); err != nil {
+			return nil, err
+		}
+		converted, err := V2ToV1(in)
+		if err != nil {
+			return nil, err
+		}
+		out = converted
+	default:
+		return nil, fmt.Errorf("unsupported conversion of %s from %s to %s", typeMeta.Kind, typeMeta.APIVersion, desiredAPIVersion)
+	}
+
+	return json.Marshal(out)
+}
+
+// WebhookHandler serves the ConversionReview requests of the API server for CRD Jinghzhu. Both
+// apiextensions.k8s.io/v1 and v1beta1 reviews are accepted, and the response has the version of the request.
+type WebhookHandler struct{}
+
+// NewWebhookHandler returns the conversion webhook handler. Serve it over TLS at WebhookPath.
+func NewWebhookHandler() *WebhookHandler {
+	return &WebhookHandler{}
+}
+
+func (h *WebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+	// The v1beta1 ConversionReview has the same shape as the v1 one.
+	review := &apiextensionsv1.ConversionReview{}
+	if err = json.Unmarshal(body, review); err != nil || review.Request == nil {
+		http.Error(w, fmt.Sprintf("fail to decode ConversionReview: %v", err), http.StatusBadRequest)
+
+		return
+	}
+
+	review.Response = convertReview(review.Request)
+	review.Request = nil
+	data, err := json.Marshal(review)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(data)
+}
+
+// convertReview converts all objects of the request. A single failure fails the whole review.
+func convertReview(request *apiextensionsv1.ConversionRequest) *apiextensionsv1.ConversionResponse {
+	response := &apiextensionsv1.ConversionResponse{
+		UID:              request.UID,
+		ConvertedObjects: make([]runtime.RawExtension, 0, len(request.Objects)),
+		Result:           metav1.Status{Status: metav1.StatusSuccess},
+	}
+	for _, obj := range request.Objects {
+		converted, err := Convert(obj.Raw, request.DesiredAPIVersion)
+		if err != nil {
+			fmt.Printf("Fail to convert Jinghzhu to %s: %v\n", request.DesiredAPIVersion, err)
+			response.ConvertedObjects = nil
+			response.Result = metav1.Status{Status: metav1.StatusFailure, Message: err.Error()}
+
+			return response
+		}
+		response.ConvertedObjects = append(response.ConvertedObjects, runtime.RawExtension{Raw: converted})
+	}
+
+	return response
+}
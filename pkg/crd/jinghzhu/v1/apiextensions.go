@@ -0,0 +1,213 @@
+package v1
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
+	apiextensionsinstall "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/install"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	apiextensionsclientset "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	// CRDAPIVersionV1 means the cluster serves apiextensions.k8s.io/v1 (Kubernetes >= v1.16).
+	CRDAPIVersionV1 string = "v1"
+	// CRDAPIVersionV1beta1 means the cluster only serves apiextensions.k8s.io/v1beta1.
+	CRDAPIVersionV1beta1 string = "v1beta1"
+)
+
+var (
+	// apiextensionsScheme knows the internal, v1 and v1beta1 CRD types and the conversions between them.
+	apiextensionsScheme = runtime.NewScheme()
+)
+
+func init() {
+	apiextensionsinstall.Install(apiextensionsScheme)
+}
+
+// DetectCRDAPIVersion asks the discovery API which apiextensions.k8s.io version the cluster serves.
+// It prefers v1 and falls back to v1beta1 on clusters older than Kubernetes v1.16. The versions are
+// read from the API groups in a single request.
+func DetectCRDAPIVersion(discoveryClient discovery.DiscoveryInterface) (string, error) {
+	groups, err := discoveryClient.ServerGroups()
+	if err != nil {
+		return "", err
+	}
+	served := make(map[string]bool)
+	for _, group := range groups.Groups {
+		if group.Name != apiextensionsv1.GroupName {
+			continue
+		}
+		for _, version := range group.Versions {
+			served[version.Version] = true
+		}
+	}
+	switch {
+	case served[CRDAPIVersionV1]:
+		return CRDAPIVersionV1, nil
+	case served[CRDAPIVersionV1beta1]:
+		return CRDAPIVersionV1beta1, nil
+	}
+
+	return "", fmt.Errorf("the cluster serves neither %s nor %s", apiextensionsv1.SchemeGroupVersion, apiextensionsv1beta1.SchemeGroupVersion)
+}
+
+// crdClient talks to the CRD API of the version served by the cluster. Objects passed in and returned
+// are always apiextensions.k8s.io/v1. They are converted to and from v1beta1 if the cluster needs it.
+type crdClient struct {
+	clientSet apiextensionsclientset.Interface
+	version   string
+}
+
+// newCRDClient detects the apiextensions API version of the cluster and returns a crdClient for it.
+func newCRDClient(clientSet apiextensionsclientset.Interface) (*crdClient, error) {
+	version, err := DetectCRDAPIVersion(clientSet.Discovery())
+	if err != nil {
+		return nil, err
+	}
+
+	return &crdClient{clientSet: clientSet, version: version}, nil
+}
+
+func (c *crdClient) create(ctx context.Context, crd *apiextensionsv1.CustomResourceDefinition) (*apiextensionsv1.CustomResourceDefinition, error) {
+	if c.version == CRDAPIVersionV1 {
+		return c.clientSet.ApiextensionsV1().CustomResourceDefinitions().Create(ctx, crd, metav1.CreateOptions{})
+	}
+	crdV1beta1, err := toV1beta1(crd)
+	if err != nil {
+		return nil, err
+	}
+	crdV1beta1, err = c.clientSet.ApiextensionsV1beta1().CustomResourceDefinitions().Create(ctx, crdV1beta1, metav1.CreateOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	return fromV1beta1(crdV1beta1)
+}
+
+func (c *crdClient) get(ctx context.Context, name string) (*apiextensionsv1.CustomResourceDefinition, error) {
+	if c.version == CRDAPIVersionV1 {
+		return c.clientSet.ApiextensionsV1().CustomResourceDefinitions().Get(ctx, name, metav1.GetOptions{})
+	}
+	crdV1beta1, err := c.clientSet.ApiextensionsV1beta1().CustomResourceDefinitions().Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	return fromV1beta1(crdV1beta1)
+}
+
+func (c *crdClient) update(ctx context.Context, crd *apiextensionsv1.CustomResourceDefinition) (*apiextensionsv1.CustomResourceDefinition, error) {
+	if c.version == CRDAPIVersionV1 {
+		return c.clientSet.ApiextensionsV1().CustomResourceDefinitions().Update(ctx, crd, metav1.UpdateOptions{})
+	}
+	crdV1beta1, err := toV1beta1(crd)
+	if err != nil {
+		return nil, err
+	}
+	crdV1beta1, err = c.clientSet.ApiextensionsV1beta1().CustomResourceDefinitions().Update(ctx, crdV1beta1, metav1.UpdateOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	return fromV1beta1(crdV1beta1)
+}
+
+func (c *crdClient) delete(ctx context.Context, name string, opts metav1.DeleteOptions) error {
+	if c.version == CRDAPIVersionV1 {
+		return c.clientSet.ApiextensionsV1().CustomResourceDefinitions().Delete(ctx, name, opts)
+	}
+
+	return c.clientSet.ApiextensionsV1beta1().CustomResourceDefinitions().Delete(ctx, name, opts)
+}
+
+// listWatch returns a ListerWatcher of the CRD with the given name. Its objects are in the version served
+// by the cluster, see objectType and toV1.
+func (c *crdClient) listWatch(ctx context.Context, name string) cache.ListerWatcher {
+	fieldSelector := fields.OneTermEqualSelector("metadata.name", name).String()
+	if c.version == CRDAPIVersionV1 {
+		crds := c.clientSet.ApiextensionsV1().CustomResourceDefinitions()
+
+		return &cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				options.FieldSelector = fieldSelector
+				return crds.List(ctx, options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				options.FieldSelector = fieldSelector
+				return crds.Watch(ctx, options)
+			},
+		}
+	}
+	crds := c.clientSet.ApiextensionsV1beta1().CustomResourceDefinitions()
+
+	return &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			options.FieldSelector = fieldSelector
+			return crds.List(ctx, options)
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			options.FieldSelector = fieldSelector
+			return crds.Watch(ctx, options)
+		},
+	}
+}
+
+// objectType returns an empty CRD object of the version served by the cluster.
+func (c *crdClient) objectType() runtime.Object {
+	if c.version == CRDAPIVersionV1 {
+		return &apiextensionsv1.CustomResourceDefinition{}
+	}
+
+	return &apiextensionsv1beta1.CustomResourceDefinition{}
+}
+
+// toV1 returns the given CRD object as apiextensions.k8s.io/v1.
+func toV1(obj runtime.Object) (*apiextensionsv1.CustomResourceDefinition, error) {
+	switch crd := obj.(type) {
+	case *apiextensionsv1.CustomResourceDefinition:
+		return crd, nil
+	case *apiextensionsv1beta1.CustomResourceDefinition:
+		return fromV1beta1(crd)
+	}
+
+	return nil, fmt.Errorf("unexpected object %T, expect a CustomResourceDefinition", obj)
+}
+
+// toV1beta1 converts a v1 CRD into v1beta1 through the internal apiextensions type.
+func toV1beta1(in *apiextensionsv1.CustomResourceDefinition) (*apiextensionsv1beta1.CustomResourceDefinition, error) {
+	internal := &apiextensions.CustomResourceDefinition{}
+	if err := apiextensionsScheme.Convert(in, internal, nil); err != nil {
+		return nil, fmt.Errorf("fail to convert CRD %s to internal version: %v", in.GetName(), err)
+	}
+	out := &apiextensionsv1beta1.CustomResourceDefinition{}
+	if err := apiextensionsScheme.Convert(internal, out, nil); err != nil {
+		return nil, fmt.Errorf("fail to convert CRD %s to v1beta1: %v", in.GetName(), err)
+	}
+	out.SetGroupVersionKind(apiextensionsv1beta1.SchemeGroupVersion.WithKind("CustomResourceDefinition"))
+
+	return out, nil
+}
+
+// fromV1beta1 converts a v1beta1 CRD into v1 through the internal apiextensions type.
+func fromV1beta1(in *apiextensionsv1beta1.CustomResourceDefinition) (*apiextensionsv1.CustomResourceDefinition, error) {
+	internal := &apiextensions.CustomResourceDefinition{}
+	if err := apiextensionsScheme.Convert(in, internal, nil); err != nil {
+		return nil, fmt.Errorf("fail to convert CRD %s to internal version: %v", in.GetName(), err)
+	}
+	out := &apiextensionsv1.CustomResourceDefinition{}
+	if err := apiextensionsScheme.Convert(internal, out, nil); err != nil {
+		return nil, fmt.Errorf("fail to convert CRD %s to v1: %v", in.GetName(), err)
+	}
+	out.SetGroupVersionKind(apiextensionsv1.SchemeGroupVersion.WithKind("CustomResourceDefinition"))
+
+	return out, nil
+}
@@ -0,0 +1,77 @@
+package v1
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestDetectCRDAPIVersion(t *testing.T) {
+	tests := []struct {
+		name     string
+		versions []string
+		want     string
+		wantErr  bool
+	}{
+		{"v1 and v1beta1", []string{CRDAPIVersionV1beta1, CRDAPIVersionV1}, CRDAPIVersionV1, false},
+		{"v1beta1 only", []string{CRDAPIVersionV1beta1}, CRDAPIVersionV1beta1, false},
+		{"neither", nil, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DetectCRDAPIVersion(newFakeClientSet(tt.versions).Discovery())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DetectCRDAPIVersion() error = %v, want error %t", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("DetectCRDAPIVersion() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCRDClientV1beta1(t *testing.T) {
+	clientSet := newFakeClientSet([]string{CRDAPIVersionV1beta1})
+	crdClient, err := newCRDClient(clientSet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if crdClient.version != CRDAPIVersionV1beta1 {
+		t.Fatalf("got a %s client, want %s", crdClient.version, CRDAPIVersionV1beta1)
+	}
+	want := NewCustomResourceDefinition()
+
+	created, err := crdClient.create(context.Background(), want)
+	if err != nil {
+		t.Fatalf("create() error = %v", err)
+	}
+	actions := clientSet.Actions()
+	createAction, ok := actions[len(actions)-1].(k8stesting.CreateAction)
+	if !ok || createAction.GetResource() != apiextensionsv1beta1.SchemeGroupVersion.WithResource("customresourcedefinitions") {
+		t.Fatalf("last action is %v, want a create of v1beta1 customresourcedefinitions", actions[len(actions)-1])
+	}
+	sent, ok := createAction.GetObject().(*apiextensionsv1beta1.CustomResourceDefinition)
+	if !ok {
+		t.Fatalf("created a %T, want a v1beta1 CRD", createAction.GetObject())
+	}
+	if sent.Name != CRDName || sent.Spec.Group != SchemeGroupVersion.Group || sent.Spec.Names.Plural != Plural || len(sent.Spec.Versions) != len(want.Spec.Versions) {
+		t.Errorf("sent %+v, want the converted definition", sent)
+	}
+
+	got, err := crdClient.get(context.Background(), CRDName)
+	if err != nil {
+		t.Fatalf("get() error = %v", err)
+	}
+	if !reflect.DeepEqual(got.Spec.Names, want.Spec.Names) || got.Spec.Group != want.Spec.Group || got.Spec.Scope != want.Spec.Scope {
+		t.Errorf("got names %+v of group %s, want %+v of group %s", got.Spec.Names, got.Spec.Group, want.Spec.Names, want.Spec.Group)
+	}
+	if !reflect.DeepEqual(created.Spec.Versions, got.Spec.Versions) {
+		t.Errorf("create() returned versions %+v, get() returned %+v", created.Spec.Versions, got.Spec.Versions)
+	}
+	if len(got.Spec.Versions) != len(want.Spec.Versions) || got.Spec.Versions[0].Name != want.Spec.Versions[0].Name {
+		t.Errorf("got versions %+v, want %+v", got.Spec.Versions, want.Spec.Versions)
+	}
+}
@@ -22,6 +22,7 @@ import (
 	"context"
 
 	jinghzhuv1 "github.com/jinghzhu/KubernetesCRD/pkg/crd/jinghzhu/v1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	labels "k8s.io/apimachinery/pkg/labels"
 	schema "k8s.io/apimachinery/pkg/runtime/schema"
@@ -36,9 +37,9 @@ type FakeJinghzhus struct {
 	ns   string
 }
 
-var jinghzhusResource = schema.GroupVersionResource{Group: "jinghzhu.com", Version: "v1", Resource: "jinghzhus"}
+var jinghzhusResource = schema.GroupVersionResource{Group: "jinghzhu.io", Version: "v1", Resource: "jinghzhus"}
 
-var jinghzhusKind = schema.GroupVersionKind{Group: "jinghzhu.com", Version: "v1", Kind: "Jinghzhu"}
+var jinghzhusKind = schema.GroupVersionKind{Group: "jinghzhu.io", Version: "v1", Kind: "Jinghzhu"}
 
 // Get takes name of the jinghzhu, and returns the corresponding jinghzhu object, and an error if there is any.
 func (c *FakeJinghzhus) Get(ctx context.Context, name string, options v1.GetOptions) (result *jinghzhuv1.Jinghzhu, err error) {
@@ -102,6 +103,18 @@ func (c *FakeJinghzhus) Update(ctx context.Context, jinghzhu *jinghzhuv1.Jinghzh
 	return obj.(*jinghzhuv1.Jinghzhu), err
 }
 
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+func (c *FakeJinghzhus) UpdateStatus(ctx context.Context, jinghzhu *jinghzhuv1.Jinghzhu, opts v1.UpdateOptions) (*jinghzhuv1.Jinghzhu, error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewUpdateSubresourceAction(jinghzhusResource, "status", c.ns, jinghzhu), &jinghzhuv1.Jinghzhu{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*jinghzhuv1.Jinghzhu), err
+}
+
 // Delete takes name of the jinghzhu and deletes it. Returns an error if one occurs.
 func (c *FakeJinghzhus) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
 	_, err := c.Fake.
@@ -128,3 +141,25 @@ func (c *FakeJinghzhus) Patch(ctx context.Context, name string, pt types.PatchTy
 	}
 	return obj.(*jinghzhuv1.Jinghzhu), err
 }
+
+// GetScale takes name of the jinghzhu, and returns the corresponding scale object, and an error if there is any.
+func (c *FakeJinghzhus) GetScale(ctx context.Context, jinghzhuName string, options v1.GetOptions) (result *autoscalingv1.Scale, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewGetSubresourceAction(jinghzhusResource, c.ns, "scale", jinghzhuName), &autoscalingv1.Scale{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*autoscalingv1.Scale), err
+}
+
+// UpdateScale takes the representation of a scale and updates it. Returns the server's representation of the scale, and an error, if there is any.
+func (c *FakeJinghzhus) UpdateScale(ctx context.Context, jinghzhuName string, scale *autoscalingv1.Scale, opts v1.UpdateOptions) (result *autoscalingv1.Scale, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewUpdateSubresourceAction(jinghzhusResource, "scale", c.ns, scale), &autoscalingv1.Scale{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*autoscalingv1.Scale), err
+}
@@ -24,6 +24,7 @@ import (
 
 	v1 "github.com/jinghzhu/KubernetesCRD/pkg/crd/jinghzhu/v1"
 	scheme "github.com/jinghzhu/KubernetesCRD/pkg/crd/jinghzhu/v1/apis/clientset/versioned/scheme"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	types "k8s.io/apimachinery/pkg/types"
 	watch "k8s.io/apimachinery/pkg/watch"
@@ -40,12 +41,16 @@ type JinghzhusGetter interface {
 type JinghzhuInterface interface {
 	Create(ctx context.Context, jinghzhu *v1.Jinghzhu, opts metav1.CreateOptions) (*v1.Jinghzhu, error)
 	Update(ctx context.Context, jinghzhu *v1.Jinghzhu, opts metav1.UpdateOptions) (*v1.Jinghzhu, error)
+	UpdateStatus(ctx context.Context, jinghzhu *v1.Jinghzhu, opts metav1.UpdateOptions) (*v1.Jinghzhu, error)
 	Delete(ctx context.Context, name string, opts metav1.DeleteOptions) error
 	DeleteCollection(ctx context.Context, opts metav1.DeleteOptions, listOpts metav1.ListOptions) error
 	Get(ctx context.Context, name string, opts metav1.GetOptions) (*v1.Jinghzhu, error)
 	List(ctx context.Context, opts metav1.ListOptions) (*v1.JinghzhuList, error)
 	Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error)
 	Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (result *v1.Jinghzhu, err error)
+	GetScale(ctx context.Context, jinghzhuName string, options metav1.GetOptions) (*autoscalingv1.Scale, error)
+	UpdateScale(ctx context.Context, jinghzhuName string, scale *autoscalingv1.Scale, opts metav1.UpdateOptions) (*autoscalingv1.Scale, error)
+
 	JinghzhuExpansion
 }
 
@@ -135,6 +140,22 @@ func (c *jinghzhus) Update(ctx context.Context, jinghzhu *v1.Jinghzhu, opts meta
 	return
 }
 
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+func (c *jinghzhus) UpdateStatus(ctx context.Context, jinghzhu *v1.Jinghzhu, opts metav1.UpdateOptions) (result *v1.Jinghzhu, err error) {
+	result = &v1.Jinghzhu{}
+	err = c.client.Put().
+		Namespace(c.ns).
+		Resource("jinghzhus").
+		Name(jinghzhu.Name).
+		SubResource("status").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(jinghzhu).
+		Do(ctx).
+		Into(result)
+	return
+}
+
 // Delete takes name of the jinghzhu and deletes it. Returns an error if one occurs.
 func (c *jinghzhus) Delete(ctx context.Context, name string, opts metav1.DeleteOptions) error {
 	return c.client.Delete().
@@ -176,3 +197,32 @@ func (c *jinghzhus) Patch(ctx context.Context, name string, pt types.PatchType,
 		Into(result)
 	return
 }
+
+// GetScale takes name of the jinghzhu, and returns the corresponding autoscalingv1.Scale object, and an error if there is any.
+func (c *jinghzhus) GetScale(ctx context.Context, jinghzhuName string, options metav1.GetOptions) (result *autoscalingv1.Scale, err error) {
+	result = &autoscalingv1.Scale{}
+	err = c.client.Get().
+		Namespace(c.ns).
+		Resource("jinghzhus").
+		Name(jinghzhuName).
+		SubResource("scale").
+		VersionedParams(&options, scheme.ParameterCodec).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// UpdateScale takes the top resource name and the representation of a scale and updates it. Returns the server's representation of the scale, and an error, if there is any.
+func (c *jinghzhus) UpdateScale(ctx context.Context, jinghzhuName string, scale *autoscalingv1.Scale, opts metav1.UpdateOptions) (result *autoscalingv1.Scale, err error) {
+	result = &autoscalingv1.Scale{}
+	err = c.client.Put().
+		Namespace(c.ns).
+		Resource("jinghzhus").
+		Name(jinghzhuName).
+		SubResource("scale").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(scale).
+		Do(ctx).
+		Into(result)
+	return
+}
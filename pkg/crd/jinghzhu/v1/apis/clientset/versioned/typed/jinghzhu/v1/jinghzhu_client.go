@@ -29,7 +29,7 @@ type JinghzhuV1Interface interface {
 	JinghzhusGetter
 }
 
-// JinghzhuV1Client is used to interact with features provided by the jinghzhu.com group.
+// JinghzhuV1Client is used to interact with features provided by the jinghzhu.io group.
 type JinghzhuV1Client struct {
 	restClient rest.Interface
 }
@@ -52,7 +52,7 @@ func (f *genericInformer) Lister() cache.GenericLister {
 // TODO extend this to unknown resources with a client pool
 func (f *sharedInformerFactory) ForResource(resource schema.GroupVersionResource) (GenericInformer, error) {
 	switch resource {
-	// Group=jinghzhu.com, Version=v1
+	// Group=jinghzhu.io, Version=v1
 	case v1.SchemeGroupVersion.WithResource("jinghzhus"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Jinghzhu().V1().Jinghzhus().Informer()}, nil
 
@@ -1,149 +1,307 @@
 package client
 
 import (
+	"context"
 	"encoding/json"
-	"fmt"
-	"time"
+	"reflect"
 
 	jinghzhuv1 "github.com/jinghzhu/KubernetesCRD/pkg/crd/jinghzhu/v1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	apimachinerytypes "k8s.io/apimachinery/pkg/types"
-
-	"github.com/jinghzhu/KubernetesCRD/pkg/types"
-	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/retry"
 )
 
-// WaitForInstanceProcessed is used for monitor the creation of a CRD instance.
-func (c *Client) WaitForInstanceProcessed(name string) error {
-	return wait.Poll(time.Second, 3*time.Second, func() (bool, error) {
-		instance, err := c.Get(name, metav1.GetOptions{})
-		if err == nil && instance.Status.State == types.StatePending {
-			return true, nil
-		}
-		fmt.Printf("Fail to wait for CRD instance processed: %+v\n", err)
-
-		return false, err
-	})
-}
-
 // Create post an instance of CRD into Kubernetes with given create options.
 func (c *Client) Create(obj *jinghzhuv1.Jinghzhu, opts metav1.CreateOptions) (*jinghzhuv1.Jinghzhu, error) {
-	return c.clientset.JinghzhuV1().Jinghzhus(c.namespace).Create(c.GetContext(), obj, opts)
+	return c.CreateWithContext(c.GetContext(), obj, opts)
+}
+
+// CreateWithContext is Create with the given context. The instance is created with finalizer
+// jinghzhuv1.FinalizerPodCleanup, so it isn't removed before the controller has deleted its Pods.
+func (c *Client) CreateWithContext(ctx context.Context, obj *jinghzhuv1.Jinghzhu, opts metav1.CreateOptions) (*jinghzhuv1.Jinghzhu, error) {
+	if !jinghzhuv1.ContainsFinalizer(obj, jinghzhuv1.FinalizerPodCleanup) {
+		obj = obj.DeepCopy()
+		jinghzhuv1.AddFinalizer(obj, jinghzhuv1.FinalizerPodCleanup)
+	}
+
+	return c.clientset.JinghzhuV1().Jinghzhus(c.namespace).Create(ctx, obj, opts)
 }
 
 // CreateDefault post an instance of CRD into Kubernetes without create options.
 func (c *Client) CreateDefault(obj *jinghzhuv1.Jinghzhu) (*jinghzhuv1.Jinghzhu, error) {
-	return c.clientset.JinghzhuV1().Jinghzhus(c.namespace).Create(c.GetContext(), obj, metav1.CreateOptions{})
+	return c.CreateWithContext(c.GetContext(), obj, metav1.CreateOptions{})
 }
 
 // Update puts new instance of CRD to replace the old one by given update options.
 func (c *Client) Update(obj *jinghzhuv1.Jinghzhu, opts metav1.UpdateOptions) (*jinghzhuv1.Jinghzhu, error) {
-	return c.clientset.JinghzhuV1().Jinghzhus(c.namespace).Update(c.GetContext(), obj, opts)
+	return c.UpdateWithContext(c.GetContext(), obj, opts)
+}
+
+// UpdateWithContext is Update with the given context.
+func (c *Client) UpdateWithContext(ctx context.Context, obj *jinghzhuv1.Jinghzhu, opts metav1.UpdateOptions) (*jinghzhuv1.Jinghzhu, error) {
+	return c.clientset.JinghzhuV1().Jinghzhus(c.namespace).Update(ctx, obj, opts)
 }
 
 // UpdateDefault puts new instance of CRD to replace the old one without update options.
 func (c *Client) UpdateDefault(obj *jinghzhuv1.Jinghzhu) (*jinghzhuv1.Jinghzhu, error) {
-	return c.clientset.JinghzhuV1().Jinghzhus(c.namespace).Update(c.GetContext(), obj, metav1.UpdateOptions{})
+	return c.UpdateWithContext(c.GetContext(), obj, metav1.UpdateOptions{})
+}
+
+// UpdateStatus puts the status of given CRD instance via the status subresource, which is /status.
+// Any change to other fields is ignored by Kubernetes.
+func (c *Client) UpdateStatus(obj *jinghzhuv1.Jinghzhu, opts metav1.UpdateOptions) (*jinghzhuv1.Jinghzhu, error) {
+	return c.UpdateStatusWithContext(c.GetContext(), obj, opts)
+}
+
+// UpdateStatusWithContext is UpdateStatus with the given context.
+func (c *Client) UpdateStatusWithContext(ctx context.Context, obj *jinghzhuv1.Jinghzhu, opts metav1.UpdateOptions) (*jinghzhuv1.Jinghzhu, error) {
+	return c.clientset.JinghzhuV1().Jinghzhus(c.namespace).UpdateStatus(ctx, obj, opts)
 }
 
-// UpdateSpecAndStatus updates the spec and status filed of CRD.
-// If only want to update some sub-resource, please use Patch instead.
+// UpdateSpecAndStatus updates the spec and status filed of CRD. The state change is checked the same
+// way as PatchStatus does.
+// Because status is a subresource, it takes two requests: spec goes to the main resource and status goes
+// to /status. Each of them is retried on conflict and skipped if nothing changes, see Mutate. If only want
+// to update some sub-resource, please use Patch instead.
 func (c *Client) UpdateSpecAndStatus(name string, jinghzhuSpec *jinghzhuv1.JinghzhuSpec, jinghzhuStatus *jinghzhuv1.JinghzhuStatus) (*jinghzhuv1.Jinghzhu, error) {
-	instance, err := c.Get(name, metav1.GetOptions{})
+	return c.UpdateSpecAndStatusWithContext(c.GetContext(), name, jinghzhuSpec, jinghzhuStatus)
+}
+
+// UpdateSpecAndStatusWithContext is UpdateSpecAndStatus with the given context.
+func (c *Client) UpdateSpecAndStatusWithContext(ctx context.Context, name string, jinghzhuSpec *jinghzhuv1.JinghzhuSpec, jinghzhuStatus *jinghzhuv1.JinghzhuStatus) (*jinghzhuv1.Jinghzhu, error) {
+	_, err := c.Mutate(ctx, name, func(instance *jinghzhuv1.Jinghzhu) error {
+		jinghzhuSpec.DeepCopyInto(&instance.Spec)
+
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	instance.Spec = *jinghzhuSpec
-	instance.Status = *jinghzhuStatus
+	return c.MutateStatus(ctx, name, func(instance *jinghzhuv1.Jinghzhu) error {
+		jinghzhuStatus.DeepCopyInto(&instance.Status)
 
-	return c.Update(instance, metav1.UpdateOptions{})
+		return nil
+	})
 }
 
 // Patch applies the patch and returns the patched Jinghzhu v1 instance.
 func (c *Client) Patch(name string, pt apimachinerytypes.PatchType, data []byte, subresources ...string) (*jinghzhuv1.Jinghzhu, error) {
-	var result jinghzhuv1.Jinghzhu
-	err := c.clientset.RESTClient().Patch(pt).
-		Namespace(c.namespace).
-		Resource(c.plural).
-		SubResource(subresources...).
-		Name(name).
-		Body(data).
-		Do(c.GetContext()).
-		Into(&result)
+	return c.PatchWithContext(c.GetContext(), name, pt, data, subresources...)
+}
 
-	return &result, err
+// PatchWithContext is Patch with the given context.
+func (c *Client) PatchWithContext(ctx context.Context, name string, pt apimachinerytypes.PatchType, data []byte, subresources ...string) (*jinghzhuv1.Jinghzhu, error) {
+	return c.clientset.JinghzhuV1().Jinghzhus(c.namespace).Patch(ctx, name, pt, data, metav1.PatchOptions{}, subresources...)
 }
 
-// PatchJSONType uses JSON Type (RFC6902) in PATCH.
-func (c *Client) PatchJSONType(name string, ops []PatchJSONTypeOps) (*jinghzhuv1.Jinghzhu, error) {
+// PatchJSONType uses JSON Type (RFC6902) in PATCH. Pass SubresourceStatus to patch the status subresource.
+func (c *Client) PatchJSONType(name string, ops []PatchJSONTypeOps, subresources ...string) (*jinghzhuv1.Jinghzhu, error) {
+	return c.PatchJSONTypeWithContext(c.GetContext(), name, ops, subresources...)
+}
+
+// PatchJSONTypeWithContext is PatchJSONType with the given context.
+func (c *Client) PatchJSONTypeWithContext(ctx context.Context, name string, ops []PatchJSONTypeOps, subresources ...string) (*jinghzhuv1.Jinghzhu, error) {
 	patchBytes, err := json.Marshal(ops)
 	if err != nil {
 		return nil, err
 	}
 
-	return c.clientset.JinghzhuV1().Jinghzhus(c.namespace).Patch(c.GetContext(), name, apimachinerytypes.JSONPatchType, patchBytes, metav1.PatchOptions{})
+	return c.PatchWithContext(ctx, name, apimachinerytypes.JSONPatchType, patchBytes, subresources...)
+}
+
+// PatchWithBuilder applies the JSON Patch built by builder. It fails without sending anything if the
+// builder has an invalid path. Pass SubresourceStatus to patch the status subresource.
+func (c *Client) PatchWithBuilder(name string, builder *JSONPatchBuilder, subresources ...string) (*jinghzhuv1.Jinghzhu, error) {
+	return c.PatchWithBuilderWithContext(c.GetContext(), name, builder, subresources...)
+}
+
+// PatchWithBuilderWithContext is PatchWithBuilder with the given context.
+func (c *Client) PatchWithBuilderWithContext(ctx context.Context, name string, builder *JSONPatchBuilder, subresources ...string) (*jinghzhuv1.Jinghzhu, error) {
+	ops, err := builder.Build()
+	if err != nil {
+		return nil, err
+	}
+
+	return c.PatchJSONTypeWithContext(ctx, name, ops, subresources...)
 }
 
 // PatchSpec only updates the spec field of Jinghzhu v1, which is /spec.
 func (c *Client) PatchSpec(name string, jinghzhuSpec *jinghzhuv1.JinghzhuSpec) (*jinghzhuv1.Jinghzhu, error) {
-	ops := make([]PatchJSONTypeOps, 1, 1)
-	ops[0].Op = PatchJSONTypeReplace
-	ops[0].Path = "/spec"
-	ops[0].Value = jinghzhuSpec
+	return c.PatchSpecWithContext(c.GetContext(), name, jinghzhuSpec)
+}
 
-	return c.PatchJSONType(name, ops)
+// PatchSpecWithContext is PatchSpec with the given context.
+func (c *Client) PatchSpecWithContext(ctx context.Context, name string, jinghzhuSpec *jinghzhuv1.JinghzhuSpec) (*jinghzhuv1.Jinghzhu, error) {
+	return c.PatchWithBuilderWithContext(ctx, name, NewJSONPatchBuilder().Replace("/spec", jinghzhuSpec))
 }
 
-// PatchStatus only updates the status field of Jinghzhu v1, which is /status.
+// PatchStatus moves the status of Jinghzhu v1 to the state and message of jinghzhuStatus via the status
+// subresource. Only /status/state, /status/message and /status/history are patched, the other fields are
+// left to the controller. The move to the new state must be allowed by types.Transition, and it is
+// recorded in Status.History, which can't be overwritten by the caller. The patch fails if the state
+// changes in between.
 func (c *Client) PatchStatus(name string, jinghzhuStatus *jinghzhuv1.JinghzhuStatus) (*jinghzhuv1.Jinghzhu, error) {
-	ops := make([]PatchJSONTypeOps, 1, 1)
-	ops[0].Op = PatchJSONTypeReplace
-	ops[0].Path = "/status"
-	ops[0].Value = jinghzhuStatus
+	return c.PatchStatusWithContext(c.GetContext(), name, jinghzhuStatus)
+}
+
+// PatchStatusWithContext is PatchStatus with the given context.
+func (c *Client) PatchStatusWithContext(ctx context.Context, name string, jinghzhuStatus *jinghzhuv1.JinghzhuStatus) (*jinghzhuv1.Jinghzhu, error) {
+	instance, err := c.GetWithContext(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	status, err := nextStatus(instance, jinghzhuStatus)
+	if err != nil {
+		return nil, err
+	}
+
+	builder := NewJSONPatchBuilder()
+	if instance.Status.State != "" {
+		builder.Test("/status/state", instance.Status.State)
+	} else {
+		// An empty state may be "" or absent, and the API server drops status on create, so a new instance
+		// may have no /status at all. A test op can't tell these apart, so test that the instance hasn't
+		// changed at all instead.
+		builder.Test("/metadata/resourceVersion", instance.GetResourceVersion())
+		if reflect.DeepEqual(instance.Status, jinghzhuv1.JinghzhuStatus{}) {
+			builder.Add("/status", map[string]interface{}{})
+		}
+	}
+	builder.Add("/status/state", status.State)
+	builder.Add("/status/message", status.Message)
+	if len(status.History) > 0 {
+		builder.Add("/status/history", status.History)
+	}
+
+	return c.PatchWithBuilderWithContext(ctx, name, builder, SubresourceStatus)
+}
+
+// nextStatus returns jinghzhuStatus with the state history of instance plus the move to the new state.
+func nextStatus(instance *jinghzhuv1.Jinghzhu, jinghzhuStatus *jinghzhuv1.JinghzhuStatus) (*jinghzhuv1.JinghzhuStatus, error) {
+	status := jinghzhuStatus.DeepCopy()
+	status.State = instance.Status.State
+	status.History = instance.Status.DeepCopy().History
+	if err := jinghzhuv1.SetState(status, jinghzhuStatus.State, jinghzhuStatus.Message); err != nil {
+		return nil, err
+	}
 
-	return c.PatchJSONType(name, ops)
+	return status, nil
 }
 
-// PatchSpecAndStatus performs patch for both spec and status field of Jinghzhu.
+// PatchSpecAndStatus performs patch for both spec and status field of Jinghzhu. Spec is patched on the
+// main resource first, and then status on the status subresource.
 func (c *Client) PatchSpecAndStatus(name string, jinghzhuSpec *jinghzhuv1.JinghzhuSpec, jinghzhuStatus *jinghzhuv1.JinghzhuStatus) (*jinghzhuv1.Jinghzhu, error) {
-	ops := make([]PatchJSONTypeOps, 2, 2)
-	ops[0].Op = PatchJSONTypeReplace
-	ops[0].Path = "/spec"
-	ops[0].Value = jinghzhuSpec
-	ops[1].Op = PatchJSONTypeReplace
-	ops[1].Path = "/status"
-	ops[1].Value = jinghzhuStatus
+	return c.PatchSpecAndStatusWithContext(c.GetContext(), name, jinghzhuSpec, jinghzhuStatus)
+}
+
+// PatchSpecAndStatusWithContext is PatchSpecAndStatus with the given context.
+func (c *Client) PatchSpecAndStatusWithContext(ctx context.Context, name string, jinghzhuSpec *jinghzhuv1.JinghzhuSpec, jinghzhuStatus *jinghzhuv1.JinghzhuStatus) (*jinghzhuv1.Jinghzhu, error) {
+	if _, err := c.PatchSpecWithContext(ctx, name, jinghzhuSpec); err != nil {
+		return nil, err
+	}
+
+	return c.PatchStatusWithContext(ctx, name, jinghzhuStatus)
+}
+
+// GetScale returns the scale subresource of the CRD instance. Spec.Replicas of the Scale maps to
+// Spec.Desired and Status.Replicas maps to Status.Replicas of Jinghzhu.
+func (c *Client) GetScale(name string, opts metav1.GetOptions) (*autoscalingv1.Scale, error) {
+	return c.GetScaleWithContext(c.GetContext(), name, opts)
+}
+
+// GetScaleWithContext is GetScale with the given context.
+func (c *Client) GetScaleWithContext(ctx context.Context, name string, opts metav1.GetOptions) (*autoscalingv1.Scale, error) {
+	return c.clientset.JinghzhuV1().Jinghzhus(c.namespace).GetScale(ctx, name, opts)
+}
 
-	return c.PatchJSONType(name, ops)
+// UpdateScale puts the scale subresource of the CRD instance, which changes Spec.Desired of Jinghzhu.
+func (c *Client) UpdateScale(name string, scale *autoscalingv1.Scale, opts metav1.UpdateOptions) (*autoscalingv1.Scale, error) {
+	return c.UpdateScaleWithContext(c.GetContext(), name, scale, opts)
+}
+
+// UpdateScaleWithContext is UpdateScale with the given context.
+func (c *Client) UpdateScaleWithContext(ctx context.Context, name string, scale *autoscalingv1.Scale, opts metav1.UpdateOptions) (*autoscalingv1.Scale, error) {
+	return c.clientset.JinghzhuV1().Jinghzhus(c.namespace).UpdateScale(ctx, name, scale, opts)
 }
 
 // Delete removes the CRD instance by given name and delete options.
 func (c *Client) Delete(name string, opts metav1.DeleteOptions) error {
-	return c.clientset.JinghzhuV1().Jinghzhus(c.namespace).Delete(c.GetContext(), name, opts)
+	return c.DeleteWithContext(c.GetContext(), name, opts)
+}
+
+// DeleteWithContext is Delete with the given context.
+func (c *Client) DeleteWithContext(ctx context.Context, name string, opts metav1.DeleteOptions) error {
+	return c.clientset.JinghzhuV1().Jinghzhus(c.namespace).Delete(ctx, name, opts)
+}
+
+// ForceDelete removes the CRD instance by given name and delete options without waiting for the
+// controller to clean up its Pods, i.e. it also strips finalizer jinghzhuv1.FinalizerPodCleanup. Use
+// it for instances stuck in deletion, e.g. when the controller isn't running. The Pods are left to the
+// garbage collector, which deletes them through their OwnerReferences.
+func (c *Client) ForceDelete(name string, opts metav1.DeleteOptions) error {
+	return c.ForceDeleteWithContext(c.GetContext(), name, opts)
+}
+
+// ForceDeleteWithContext is ForceDelete with the given context.
+func (c *Client) ForceDeleteWithContext(ctx context.Context, name string, opts metav1.DeleteOptions) error {
+	err := c.DeleteWithContext(ctx, name, opts)
+	if err != nil {
+		return err
+	}
+	jinghzhus := c.clientset.JinghzhuV1().Jinghzhus(c.namespace)
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		instance, err := jinghzhus.Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if !jinghzhuv1.RemoveFinalizer(instance, jinghzhuv1.FinalizerPodCleanup) {
+			return nil
+		}
+		_, err = jinghzhus.Update(ctx, instance, metav1.UpdateOptions{})
+
+		return err
+	})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+
+	return err
 }
 
 // DeleteDefault removes the CRD instance without delete options.
 func (c *Client) DeleteDefault(name string) error {
-	return c.clientset.JinghzhuV1().Jinghzhus(c.namespace).Delete(c.GetContext(), name, metav1.DeleteOptions{})
+	return c.DeleteWithContext(c.GetContext(), name, metav1.DeleteOptions{})
 }
 
 // Get returns a pointer to the CRD instance.
 func (c *Client) Get(name string, opts metav1.GetOptions) (*jinghzhuv1.Jinghzhu, error) {
-	return c.clientset.JinghzhuV1().Jinghzhus(c.namespace).Get(c.GetContext(), name, opts)
+	return c.GetWithContext(c.GetContext(), name, opts)
+}
+
+// GetWithContext is Get with the given context.
+func (c *Client) GetWithContext(ctx context.Context, name string, opts metav1.GetOptions) (*jinghzhuv1.Jinghzhu, error) {
+	return c.clientset.JinghzhuV1().Jinghzhus(c.namespace).Get(ctx, name, opts)
 }
 
 // GetDefault retrieves the crd instance without get options.
 func (c *Client) GetDefault(name string) (*jinghzhuv1.Jinghzhu, error) {
-	return c.clientset.JinghzhuV1().Jinghzhus(c.namespace).Get(c.GetContext(), name, metav1.GetOptions{})
+	return c.GetWithContext(c.GetContext(), name, metav1.GetOptions{})
 }
 
 // List returns a list of CRD instances by given list options.
 func (c *Client) List(opts metav1.ListOptions) (*jinghzhuv1.JinghzhuList, error) {
-	return c.clientset.JinghzhuV1().Jinghzhus(c.namespace).List(c.GetContext(), opts)
+	return c.ListWithContext(c.GetContext(), opts)
+}
+
+// ListWithContext is List with the given context.
+func (c *Client) ListWithContext(ctx context.Context, opts metav1.ListOptions) (*jinghzhuv1.JinghzhuList, error) {
+	return c.clientset.JinghzhuV1().Jinghzhus(c.namespace).List(ctx, opts)
 }
 
 // ListDefaultDefault returns a list of CRD instances without list options.
 func (c *Client) ListDefaultDefault() (*jinghzhuv1.JinghzhuList, error) {
-	return c.clientset.JinghzhuV1().Jinghzhus(c.namespace).List(c.GetContext(), metav1.ListOptions{})
+	return c.ListWithContext(c.GetContext(), metav1.ListOptions{})
 }
@@ -0,0 +1,291 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+
+	jinghzhuv1 "github.com/jinghzhu/KubernetesCRD/pkg/crd/jinghzhu/v1"
+	jinghzhuv1fake "github.com/jinghzhu/KubernetesCRD/pkg/crd/jinghzhu/v1/apis/clientset/versioned/fake"
+	"github.com/jinghzhu/KubernetesCRD/pkg/types"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	apimachinerytypes "k8s.io/apimachinery/pkg/types"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// newFakeClient returns a Client on the fake clientset pre-loaded with the instances.
+func newFakeClient(instances ...*jinghzhuv1.Jinghzhu) (*Client, *jinghzhuv1fake.Clientset) {
+	objects := make([]runtime.Object, 0, len(instances))
+	for _, instance := range instances {
+		objects = append(objects, instance)
+	}
+	clientset := jinghzhuv1fake.NewSimpleClientset(objects...)
+
+	return NewClientForClientset(context.Background(), clientset, testNamespace), clientset
+}
+
+// patchActions returns the patch actions recorded by the fake clientset.
+func patchActions(clientset *jinghzhuv1fake.Clientset) []k8stesting.PatchAction {
+	patches := make([]k8stesting.PatchAction, 0)
+	for _, action := range clientset.Actions() {
+		if patch, ok := action.(k8stesting.PatchAction); ok {
+			patches = append(patches, patch)
+		}
+	}
+
+	return patches
+}
+
+func TestPatchStatus(t *testing.T) {
+	running := newInstance("running", nil, 2, types.StateRunning)
+	running.ResourceVersion = "5"
+	running.Status.Replicas = 2
+	running.Status.PodNames = []string{"running-a", "running-b"}
+	running.Status.Selector = "jinghzhu.io/instance=running"
+	created := newInstance("created", nil, 1, "")
+	created.ResourceVersion = "3"
+	stateless := newInstance("stateless", nil, 1, "")
+	stateless.ResourceVersion = "4"
+	stateless.Status.Replicas = 1
+
+	tests := []struct {
+		name     string
+		instance *jinghzhuv1.Jinghzhu
+		state    types.State
+		wantTest PatchJSONTypeOps
+	}{
+		{"state set", running, types.StateSucceeded, PatchJSONTypeOps{Op: PatchJSONTypeTest, Path: "/status/state", Value: "Running"}},
+		{"no status", created, types.StatePending, PatchJSONTypeOps{Op: PatchJSONTypeTest, Path: "/metadata/resourceVersion", Value: "3"}},
+		{"no state", stateless, types.StatePending, PatchJSONTypeOps{Op: PatchJSONTypeTest, Path: "/metadata/resourceVersion", Value: "4"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, clientset := newFakeClient(tt.instance)
+
+			got, err := c.PatchStatus(tt.instance.Name, &jinghzhuv1.JinghzhuStatus{State: tt.state, Message: "moved"})
+			if err != nil {
+				t.Fatalf("PatchStatus() error = %v", err)
+			}
+			if got.Status.State != tt.state || got.Status.Message != "moved" {
+				t.Errorf("status is %q %q, want %q moved", got.Status.State, got.Status.Message, tt.state)
+			}
+			if n := len(got.Status.History); n != 1 || got.Status.History[0].To != tt.state {
+				t.Errorf("history = %+v, want the move to %s", got.Status.History, tt.state)
+			}
+			// The fields of the controller are left alone.
+			if got.Status.Replicas != tt.instance.Status.Replicas ||
+				!reflect.DeepEqual(got.Status.PodNames, tt.instance.Status.PodNames) ||
+				got.Status.Selector != tt.instance.Status.Selector {
+				t.Errorf("status = %+v, want the observed fields of %+v kept", got.Status, tt.instance.Status)
+			}
+
+			patches := patchActions(clientset)
+			if len(patches) != 1 {
+				t.Fatalf("got %d patches, want 1", len(patches))
+			}
+			if patches[0].GetSubresource() != SubresourceStatus || patches[0].GetPatchType() != apimachinerytypes.JSONPatchType {
+				t.Errorf("patch is a %s of %q, want a JSON Patch of the status", patches[0].GetPatchType(), patches[0].GetSubresource())
+			}
+			var ops []PatchJSONTypeOps
+			if err = json.Unmarshal(patches[0].GetPatch(), &ops); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(ops[0], tt.wantTest) {
+				t.Errorf("first op = %+v, want %+v", ops[0], tt.wantTest)
+			}
+			for _, op := range ops[1:] {
+				if op.Op == PatchJSONTypeTest || (op.Path != "/status" && op.Path != "/status/state" && op.Path != "/status/message" && op.Path != "/status/history") {
+					t.Errorf("unexpected op %+v", op)
+				}
+			}
+		})
+	}
+}
+
+func TestPatchStatusConflict(t *testing.T) {
+	pending := newInstance("pending", nil, 1, types.StatePending)
+	created := newInstance("created", nil, 1, "")
+	created.ResourceVersion = "1"
+	tests := []struct {
+		name     string
+		instance *jinghzhuv1.Jinghzhu
+	}{
+		{"state set", pending},
+		{"no state", created},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, clientset := newFakeClient(tt.instance)
+			// The controller moves the state on between the Get and the Patch.
+			clientset.PrependReactor("patch", jinghzhuv1.Plural, func(action k8stesting.Action) (bool, runtime.Object, error) {
+				changed := tt.instance.DeepCopy()
+				changed.ResourceVersion = "2"
+				changed.Status.State = types.StateRunning
+				err := clientset.Tracker().Update(jinghzhuv1.SchemeGroupVersion.WithResource(jinghzhuv1.Plural), changed, testNamespace)
+
+				return false, nil, err
+			})
+
+			if _, err := c.PatchStatus(tt.instance.Name, &jinghzhuv1.JinghzhuStatus{State: types.StateFailed}); err == nil {
+				t.Fatal("PatchStatus() succeeded, want the test op to fail")
+			}
+			got, err := c.Get(tt.instance.Name, metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Status.State != types.StateRunning {
+				t.Errorf("state = %q, want the one of the controller", got.Status.State)
+			}
+		})
+	}
+}
+
+// updateActions returns the update actions of the given subresource recorded by the fake clientset.
+func updateActions(clientset *jinghzhuv1fake.Clientset, subresource string) []k8stesting.UpdateAction {
+	updates := make([]k8stesting.UpdateAction, 0)
+	for _, action := range clientset.Actions() {
+		if update, ok := action.(k8stesting.UpdateAction); ok && update.GetSubresource() == subresource {
+			updates = append(updates, update)
+		}
+	}
+
+	return updates
+}
+
+// conflictOnce makes the first update of the given subresource fail with a Conflict.
+func conflictOnce(clientset *jinghzhuv1fake.Clientset, subresource string) {
+	conflicted := false
+	clientset.PrependReactor("update", jinghzhuv1.Plural, func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if conflicted || action.GetSubresource() != subresource {
+			return false, nil, nil
+		}
+		conflicted = true
+
+		return true, nil, apierrors.NewConflict(jinghzhuv1.SchemeGroupVersion.WithResource(jinghzhuv1.Plural).GroupResource(), "", fmt.Errorf("changed"))
+	})
+}
+
+func TestMutate(t *testing.T) {
+	instance := newInstance("mutate", nil, 1, types.StateRunning)
+	c, clientset := newFakeClient(instance)
+	conflictOnce(clientset, "")
+	calls := 0
+
+	got, err := c.Mutate(context.Background(), instance.Name, func(instance *jinghzhuv1.Jinghzhu) error {
+		calls++
+		instance.Spec.Desired = 3
+		// Ignored by Mutate.
+		instance.Status.Message = "changed"
+
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Mutate() error = %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("mutate was called %d times, want 2", calls)
+	}
+	if n := len(updateActions(clientset, "")); n != 2 {
+		t.Errorf("got %d updates, want the conflicting one and its retry", n)
+	}
+	if got.Spec.Desired != 3 || got.Status.Message != "" {
+		t.Errorf("got desired %d and message %q, want 3 and the status left alone", got.Spec.Desired, got.Status.Message)
+	}
+}
+
+func TestMutateStatus(t *testing.T) {
+	instance := newInstance("mutate", nil, 1, types.StatePending)
+	c, clientset := newFakeClient(instance)
+	conflictOnce(clientset, SubresourceStatus)
+	calls := 0
+
+	got, err := c.MutateStatus(context.Background(), instance.Name, func(instance *jinghzhuv1.Jinghzhu) error {
+		calls++
+		instance.Status.State = types.StateRunning
+		instance.Status.Message = "running"
+
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("MutateStatus() error = %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("mutate was called %d times, want 2", calls)
+	}
+	if n := len(updateActions(clientset, SubresourceStatus)); n != 2 {
+		t.Errorf("got %d status updates, want the conflicting one and its retry", n)
+	}
+	if got.Status.State != types.StateRunning || len(got.Status.History) != 1 || got.Status.History[0].From != types.StatePending {
+		t.Errorf("status = %+v, want the move to Running recorded", got.Status)
+	}
+
+	_, err = c.MutateStatus(context.Background(), instance.Name, func(instance *jinghzhuv1.Jinghzhu) error {
+		instance.Status.State = "Unknown"
+
+		return nil
+	})
+	if _, ok := err.(*types.InvalidTransitionError); !ok {
+		t.Errorf("MutateStatus() error = %v, want *types.InvalidTransitionError", err)
+	}
+}
+
+func TestMutateNoop(t *testing.T) {
+	errMutate := fmt.Errorf("fail")
+	tests := []struct {
+		name    string
+		status  bool
+		mutate  MutateFunc
+		wantErr error
+	}{
+		{"nothing changed", false, func(*jinghzhuv1.Jinghzhu) error { return nil }, nil},
+		{"only the status changed", false, func(instance *jinghzhuv1.Jinghzhu) error {
+			instance.Status.Message = "changed"
+
+			return nil
+		}, nil},
+		{"same value", false, func(instance *jinghzhuv1.Jinghzhu) error {
+			instance.Spec.Desired = 1
+
+			return nil
+		}, nil},
+		{"status nothing changed", true, func(*jinghzhuv1.Jinghzhu) error { return nil }, nil},
+		{"status only the spec changed", true, func(instance *jinghzhuv1.Jinghzhu) error {
+			instance.Spec.Desired = 5
+
+			return nil
+		}, nil},
+		{"error", false, func(instance *jinghzhuv1.Jinghzhu) error {
+			instance.Spec.Desired = 5
+
+			return errMutate
+		}, errMutate},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			instance := newInstance("noop", nil, 1, types.StateRunning)
+			c, clientset := newFakeClient(instance)
+
+			var err error
+			if tt.status {
+				_, err = c.MutateStatus(context.Background(), instance.Name, tt.mutate)
+			} else {
+				_, err = c.Mutate(context.Background(), instance.Name, tt.mutate)
+			}
+			if err != tt.wantErr {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			for _, action := range clientset.Actions() {
+				if action.GetVerb() != "get" {
+					t.Errorf("got action %s %s, want only reads", action.GetVerb(), action.GetSubresource())
+				}
+			}
+		})
+	}
+}
+
+// errFake is the error returned by the reactors which make a request fail.
+var errFake = fmt.Errorf("fake error")
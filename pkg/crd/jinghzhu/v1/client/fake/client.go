@@ -0,0 +1,28 @@
+// Package fake provides an in-memory client.JinghzhuClient for unit tests. It doesn't need a cluster.
+package fake
+
+import (
+	"context"
+
+	jinghzhuv1fake "github.com/jinghzhu/KubernetesCRD/pkg/crd/jinghzhu/v1/apis/clientset/versioned/fake"
+	jinghzhuv1client "github.com/jinghzhu/KubernetesCRD/pkg/crd/jinghzhu/v1/client"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Client is a client.Client backed by the generated fake clientset. Use Clientset to add reactors,
+// inspect the recorded actions or reach the object tracker.
+type Client struct {
+	*jinghzhuv1client.Client
+	Clientset *jinghzhuv1fake.Clientset
+}
+
+// NewSimpleClient returns a fake client for the given namespace, pre-loaded with objects. Like the
+// fake clientset, it processes creates, updates and deletions as-is without any validation.
+func NewSimpleClient(ctx context.Context, namespace string, objects ...runtime.Object) *Client {
+	clientset := jinghzhuv1fake.NewSimpleClientset(objects...)
+
+	return &Client{
+		Client:    jinghzhuv1client.NewClientForClientset(ctx, clientset, namespace),
+		Clientset: clientset,
+	}
+}
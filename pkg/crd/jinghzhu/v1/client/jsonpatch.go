@@ -0,0 +1,201 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	jinghzhuv1 "github.com/jinghzhu/KubernetesCRD/pkg/crd/jinghzhu/v1"
+	"github.com/jinghzhu/KubernetesCRD/pkg/crd/schema"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	errors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+var (
+	jinghzhuType  = reflect.TypeOf(jinghzhuv1.Jinghzhu{})
+	timeType      = reflect.TypeOf(metav1.Time{})
+	marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+// JSONPatchBuilder builds a JSON Patch (RFC6902) for Jinghzhu v1. Every path is checked against the
+// Jinghzhu type, so a typo fails in Build rather than at the API server. Use JSONPointer to build a path
+// out of keys which contain "/" or "~", e.g. label keys.
+//
+//	ops, err := NewJSONPatchBuilder().
+//		Test("/status/state", types.StateRunning).
+//		Replace("/spec/desired", 3).
+//		Add(JSONPointer("metadata", "labels", "app.kubernetes.io/name"), "demo").
+//		Build()
+type JSONPatchBuilder struct {
+	ops  []PatchJSONTypeOps
+	errs []error
+}
+
+// NewJSONPatchBuilder returns an empty JSONPatchBuilder.
+func NewJSONPatchBuilder() *JSONPatchBuilder {
+	return &JSONPatchBuilder{ops: make([]PatchJSONTypeOps, 0)}
+}
+
+// Add adds value at path. The path of an array element may end with "-" to append to the array.
+func (b *JSONPatchBuilder) Add(path string, value interface{}) *JSONPatchBuilder {
+	return b.op(PatchJSONTypeAdd, "", path, value)
+}
+
+// Remove removes the value at path.
+func (b *JSONPatchBuilder) Remove(path string) *JSONPatchBuilder {
+	return b.op(PatchJSONTypeRemove, "", path, nil)
+}
+
+// Replace replaces the value at path, which must exist, with value.
+func (b *JSONPatchBuilder) Replace(path string, value interface{}) *JSONPatchBuilder {
+	return b.op(PatchJSONTypeReplace, "", path, value)
+}
+
+// Move removes the value at from and adds it at path.
+func (b *JSONPatchBuilder) Move(from, path string) *JSONPatchBuilder {
+	return b.op(PatchJSONTypeMove, from, path, nil)
+}
+
+// Copy adds the value at from at path.
+func (b *JSONPatchBuilder) Copy(from, path string) *JSONPatchBuilder {
+	return b.op(PatchJSONTypeCopy, from, path, nil)
+}
+
+// Test makes the whole patch fail if the value at path isn't value. Put it first to update the
+// instance only if it hasn't changed, e.g. on /metadata/resourceVersion or /status/state.
+func (b *JSONPatchBuilder) Test(path string, value interface{}) *JSONPatchBuilder {
+	return b.op(PatchJSONTypeTest, "", path, value)
+}
+
+// Build returns the operations, or the errors of all invalid paths.
+func (b *JSONPatchBuilder) Build() ([]PatchJSONTypeOps, error) {
+	if len(b.errs) > 0 {
+		return nil, errors.NewAggregate(b.errs)
+	}
+
+	return append([]PatchJSONTypeOps(nil), b.ops...), nil
+}
+
+func (b *JSONPatchBuilder) op(op, from, path string, value interface{}) *JSONPatchBuilder {
+	// Only an operation which adds a value may point after the last element of an array.
+	appends := op == PatchJSONTypeAdd || op == PatchJSONTypeMove || op == PatchJSONTypeCopy
+	if op == PatchJSONTypeMove || op == PatchJSONTypeCopy {
+		if err := validatePath(jinghzhuType, from, false); err != nil {
+			b.errs = append(b.errs, fmt.Errorf("%s from: %v", op, err))
+		}
+	}
+	if err := validatePath(jinghzhuType, path, appends); err != nil {
+		b.errs = append(b.errs, fmt.Errorf("%s: %v", op, err))
+	}
+	b.ops = append(b.ops, PatchJSONTypeOps{Op: op, From: from, Path: path, Value: value})
+
+	return b
+}
+
+// JSONPointer joins the reference tokens into a JSON Pointer (RFC6901), escaping "~" as "~0" and "/"
+// as "~1", e.g. JSONPointer("metadata", "labels", "a/b") is "/metadata/labels/a~1b".
+func JSONPointer(tokens ...string) string {
+	var sb strings.Builder
+	for _, token := range tokens {
+		sb.WriteString("/")
+		sb.WriteString(strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1"))
+	}
+
+	return sb.String()
+}
+
+// parseJSONPointer splits a JSON Pointer into its unescaped reference tokens.
+func parseJSONPointer(path string) ([]string, error) {
+	if path == "" {
+		return []string{}, nil
+	}
+	if !strings.HasPrefix(path, "/") {
+		return nil, fmt.Errorf("path %q doesn't start with /", path)
+	}
+	tokens := strings.Split(path[1:], "/")
+	for i, token := range tokens {
+		for j := 0; j < len(token); j++ {
+			if token[j] == '~' && (j+1 == len(token) || (token[j+1] != '0' && token[j+1] != '1')) {
+				return nil, fmt.Errorf("path %q has an invalid escape, use ~0 for ~ and ~1 for /", path)
+			}
+		}
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+
+	return tokens, nil
+}
+
+// validatePath checks that path points into a value of type t according to its json tags. Below a
+// map, any key is accepted. Below a type with its own JSON encoding, any path is accepted.
+func validatePath(t reflect.Type, path string, appends bool) error {
+	tokens, err := parseJSONPointer(path)
+	if err != nil {
+		return err
+	}
+	for i, token := range tokens {
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if t == timeType {
+			return fmt.Errorf("path %q goes below %s, which is a string", path, JSONPointer(tokens[:i]...))
+		}
+		if t.Implements(marshalerType) || reflect.PtrTo(t).Implements(marshalerType) {
+			return nil
+		}
+
+		switch t.Kind() {
+		case reflect.Struct:
+			field, ok := jsonField(t, token)
+			if !ok {
+				return fmt.Errorf("path %q: %s has no field %q", path, t, token)
+			}
+			t = field
+		case reflect.Map:
+			t = t.Elem()
+		case reflect.Slice, reflect.Array:
+			if token == "-" {
+				if !appends || i != len(tokens)-1 {
+					return fmt.Errorf("path %q: - is only allowed at the end of the path of add, move and copy", path)
+				}
+			} else if n, err := strconv.Atoi(token); err != nil || n < 0 || (len(token) > 1 && token[0] == '0') {
+				return fmt.Errorf("path %q: %q is not an array index", path, token)
+			}
+			t = t.Elem()
+		case reflect.Interface:
+			return nil
+		default:
+			return fmt.Errorf("path %q goes below %s, which is a %s", path, JSONPointer(tokens[:i]...), t.Kind())
+		}
+	}
+
+	return nil
+}
+
+// jsonField returns the type of the field of struct t whose json name is name. The fields of inlined
+// structs are searched too. The names are resolved like the CRD schema does, see schema.JSONName.
+func jsonField(t reflect.Type, name string) (reflect.Type, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		fieldName, inline := schema.JSONName(field)
+		if fieldName == "-" {
+			continue
+		}
+		if inline {
+			if found, ok := jsonField(field.Type, name); ok {
+				return found, true
+			}
+
+			continue
+		}
+		if fieldName == name {
+			return field.Type, true
+		}
+	}
+
+	return nil, false
+}
@@ -0,0 +1,126 @@
+package client
+
+import (
+	"reflect"
+	"testing"
+
+	errors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+func TestJSONPointer(t *testing.T) {
+	tests := []struct {
+		tokens []string
+		want   string
+	}{
+		{nil, ""},
+		{[]string{"spec", "desired"}, "/spec/desired"},
+		{[]string{"metadata", "labels", "app.kubernetes.io/name"}, "/metadata/labels/app.kubernetes.io~1name"},
+		{[]string{"metadata", "annotations", "a~b"}, "/metadata/annotations/a~0b"},
+		{[]string{"metadata", "annotations", "~1"}, "/metadata/annotations/~01"},
+		{[]string{""}, "/"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			got := JSONPointer(tt.tokens...)
+			if got != tt.want {
+				t.Fatalf("JSONPointer(%q) = %q, want %q", tt.tokens, got, tt.want)
+			}
+			tokens, err := parseJSONPointer(got)
+			if err != nil {
+				t.Fatalf("parseJSONPointer(%q) error = %v", got, err)
+			}
+			if len(tt.tokens) == 0 {
+				tt.tokens = []string{}
+			}
+			if !reflect.DeepEqual(tokens, tt.tokens) {
+				t.Errorf("parseJSONPointer(%q) = %q, want %q", got, tokens, tt.tokens)
+			}
+		})
+	}
+}
+
+func TestParseJSONPointerInvalid(t *testing.T) {
+	for _, path := range []string{"spec", "/spec/a~", "/spec/a~2", "/spec/~x"} {
+		if _, err := parseJSONPointer(path); err == nil {
+			t.Errorf("parseJSONPointer(%q) succeeded, want an error", path)
+		}
+	}
+}
+
+func TestValidatePath(t *testing.T) {
+	tests := []struct {
+		path    string
+		appends bool
+		wantErr bool
+	}{
+		{"", false, false},
+		{"/spec", false, false},
+		{"/spec/desired", false, false},
+		{"/kind", false, false},
+		{"/apiVersion", false, false},
+		{"/metadata/name", false, false},
+		{"/metadata/labels/app.kubernetes.io~1name", false, false},
+		{"/metadata/finalizers/0", false, false},
+		{"/metadata/finalizers/-", true, false},
+		{"/metadata/creationTimestamp", false, false},
+		{"/status/state", false, false},
+		{"/status/podNames/1", false, false},
+		{"/status/conditions/0/type", false, false},
+		{"/status/history/-", true, false},
+		{"/spec/Desired", false, true},
+		{"/spec/unknown", false, true},
+		{"/TypeMeta", false, true},
+		{"/ObjectMeta/name", false, true},
+		{"/spec/desired/0", false, true},
+		{"/metadata/creationTimestamp/seconds", false, true},
+		{"/metadata/finalizers/-", false, true},
+		{"/status/history/-/to", true, true},
+		{"/metadata/finalizers/01", false, true},
+		{"/metadata/finalizers/-1", false, true},
+		{"/metadata/finalizers/x", false, true},
+		{"spec/desired", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			err := validatePath(jinghzhuType, tt.path, tt.appends)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validatePath(%q, %v) error = %v, wantErr %v", tt.path, tt.appends, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestJSONPatchBuilder(t *testing.T) {
+	ops, err := NewJSONPatchBuilder().
+		Test("/status/state", "Running").
+		Replace("/spec/desired", 3).
+		Add(JSONPointer("metadata", "labels", "app.kubernetes.io/name"), "demo").
+		Move("/metadata/labels/a", "/metadata/labels/b").
+		Remove("/metadata/annotations/a").
+		Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	want := []PatchJSONTypeOps{
+		{Op: PatchJSONTypeTest, Path: "/status/state", Value: "Running"},
+		{Op: PatchJSONTypeReplace, Path: "/spec/desired", Value: 3},
+		{Op: PatchJSONTypeAdd, Path: "/metadata/labels/app.kubernetes.io~1name", Value: "demo"},
+		{Op: PatchJSONTypeMove, From: "/metadata/labels/a", Path: "/metadata/labels/b"},
+		{Op: PatchJSONTypeRemove, Path: "/metadata/annotations/a"},
+	}
+	if !reflect.DeepEqual(ops, want) {
+		t.Errorf("Build() = %+v, want %+v", ops, want)
+	}
+
+	_, err = NewJSONPatchBuilder().
+		Replace("/spec/desired", 3).
+		Replace("/spec/replicas", 3).
+		Copy("/spec/nope", "/spec/desired").
+		Build()
+	if err == nil {
+		t.Fatal("Build() succeeded, want the invalid paths to fail")
+	}
+	if aggregate, ok := err.(errors.Aggregate); !ok || len(aggregate.Errors()) != 2 {
+		t.Errorf("Build() error = %v, want 2 errors", err)
+	}
+}
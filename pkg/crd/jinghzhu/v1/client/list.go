@@ -0,0 +1,102 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	jinghzhuv1 "github.com/jinghzhu/KubernetesCRD/pkg/crd/jinghzhu/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	apimachinerytypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/pager"
+)
+
+const (
+	// DefaultPageSize is how many CRD instances ListEach and ListStream fetch per request when the Limit
+	// of the list options is 0.
+	DefaultPageSize int64 = 500
+)
+
+// ListEach calls fn for every CRD instance matching opts. The instances are fetched in pages of
+// opts.Limit, or DefaultPageSize, so no single response holds all of them. If fn returns an error,
+// ListEach stops and returns it.
+// A continue token expires when the pages are read slower than the API server compacts, e.g. because
+// fn is slow. Then the list starts over at the latest resourceVersion, and the instances fn has already
+// got are skipped. So every instance is passed at most once, but the instances don't come from a single
+// snapshot. ListEach gives up when the relist expires again before passing any new instance.
+func (c *Client) ListEach(ctx context.Context, opts metav1.ListOptions, fn func(instance *jinghzhuv1.Jinghzhu) error) error {
+	if opts.Limit == 0 {
+		opts.Limit = DefaultPageSize
+	}
+	listPager := c.newListPager(nil)
+	seen := make(map[apimachinerytypes.UID]bool)
+
+	for {
+		passed := 0
+		err := listPager.EachListItem(ctx, opts, func(obj runtime.Object) error {
+			instance, ok := obj.(*jinghzhuv1.Jinghzhu)
+			if !ok {
+				return fmt.Errorf("unexpected object %T in the list of Jinghzhu", obj)
+			}
+			if seen[instance.GetUID()] {
+				return nil
+			}
+			seen[instance.GetUID()] = true
+			passed++
+
+			return fn(instance)
+		})
+		if !apierrors.IsResourceExpired(err) || passed == 0 {
+			return err
+		}
+		fmt.Printf("Continue token of Jinghzhu list expired after %d instances, relist\n", len(seen))
+		opts.Continue = ""
+		opts.ResourceVersion = ""
+	}
+}
+
+// newListPager returns the pager of ListEach. It returns the error when a continue token expires
+// instead of falling back to a full list, so the caller decides how to start over. If observe isn't
+// nil, it gets every page.
+func (c *Client) newListPager(observe func(list *jinghzhuv1.JinghzhuList)) *pager.ListPager {
+	listPager := pager.New(func(ctx context.Context, opts metav1.ListOptions) (runtime.Object, error) {
+		list, err := c.ListWithContext(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+		if observe != nil {
+			observe(list)
+		}
+
+		return list, nil
+	})
+	listPager.FullListIfExpired = false
+
+	return listPager
+}
+
+// ListStream is ListEach with a channel. The instances are sent on the first channel, which is closed
+// when the list is done or ctx is done. Then the error of the list, if any, is sent on the second one.
+// Keep receiving until the first channel is closed, or cancel ctx to stop early.
+func (c *Client) ListStream(ctx context.Context, opts metav1.ListOptions) (<-chan *jinghzhuv1.Jinghzhu, <-chan error) {
+	instances := make(chan *jinghzhuv1.Jinghzhu)
+	errCh := make(chan error, 1)
+	go func() {
+		defer close(errCh)
+		err := c.ListEach(ctx, opts, func(instance *jinghzhuv1.Jinghzhu) error {
+			select {
+			case instances <- instance:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+		close(instances)
+		if err != nil {
+			errCh <- err
+		}
+	}()
+
+	return instances, errCh
+}
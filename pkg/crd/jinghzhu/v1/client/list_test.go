@@ -0,0 +1,99 @@
+package client
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+
+	jinghzhuv1 "github.com/jinghzhu/KubernetesCRD/pkg/crd/jinghzhu/v1"
+	jinghzhuv1fake "github.com/jinghzhu/KubernetesCRD/pkg/crd/jinghzhu/v1/apis/clientset/versioned/fake"
+	"github.com/jinghzhu/KubernetesCRD/pkg/types"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// listPage is a page the list reactor of the fake clientset returns.
+type listPage struct {
+	names []string
+	// next is the continue token of the page.
+	next    string
+	expired bool
+}
+
+// pagedList makes the fake clientset, which ignores Limit and Continue, return the pages in order, one
+// per list request, and 410 Expired for the pages which are expired. It returns the number of lists.
+func pagedList(t *testing.T, clientset *jinghzhuv1fake.Clientset, pages []listPage) *int {
+	lists := 0
+	clientset.PrependReactor("list", jinghzhuv1.Plural, func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if lists == len(pages) {
+			t.Fatalf("unexpected list %d", lists+1)
+		}
+		page := pages[lists]
+		lists++
+		if page.expired {
+			return true, nil, apierrors.NewResourceExpired("continue token expired")
+		}
+		list := &jinghzhuv1.JinghzhuList{ListMeta: metav1.ListMeta{ResourceVersion: "1", Continue: page.next}}
+		for _, name := range page.names {
+			list.Items = append(list.Items, *newInstance(name, nil, 1, types.StateRunning))
+		}
+
+		return true, list, nil
+	})
+
+	return &lists
+}
+
+func TestListEachExpired(t *testing.T) {
+	c, clientset := newFakeClient()
+	lists := pagedList(t, clientset, []listPage{
+		{names: []string{"a", "b"}, next: "first"},
+		{expired: true},
+		// b is deleted and c is created before the relist.
+		{names: []string{"a", "c"}, next: "relisted"},
+		{names: []string{"d"}},
+	})
+	got := make([]string, 0)
+
+	err := c.ListEach(context.Background(), metav1.ListOptions{Limit: 2}, func(instance *jinghzhuv1.Jinghzhu) error {
+		got = append(got, instance.Name)
+
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ListEach() error = %v", err)
+	}
+	sort.Strings(got)
+	if want := []string{"a", "b", "c", "d"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want each instance exactly once %v", got, want)
+	}
+	if *lists != 4 {
+		t.Errorf("got %d lists, want 4", *lists)
+	}
+}
+
+func TestListEachExpiredAgain(t *testing.T) {
+	c, clientset := newFakeClient()
+	pagedList(t, clientset, []listPage{
+		{names: []string{"a"}, next: "first"},
+		{expired: true},
+		{names: []string{"a"}, next: "first"},
+		{expired: true},
+	})
+	calls := 0
+
+	err := c.ListEach(context.Background(), metav1.ListOptions{}, func(instance *jinghzhuv1.Jinghzhu) error {
+		calls++
+
+		return nil
+	})
+	if !apierrors.IsResourceExpired(err) {
+		t.Errorf("ListEach() error = %v, want the relist without progress to give up", err)
+	}
+	if calls != 1 {
+		t.Errorf("fn was called %d times, want 1", calls)
+	}
+}
@@ -0,0 +1,135 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	jinghzhuv1 "github.com/jinghzhu/KubernetesCRD/pkg/crd/jinghzhu/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	apimachinerytypes "k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// DefaultFieldManager is the field manager of Apply when ApplyOptions.FieldManager is empty.
+	DefaultFieldManager string = "jinghzhu-client"
+)
+
+// ApplyOptions configures Apply.
+type ApplyOptions struct {
+	// FieldManager is the name of the actor which owns the applied fields. Actors which own different
+	// fields of one instance must use different names. It defaults to DefaultFieldManager.
+	FieldManager string
+	// Force takes over the fields owned by other managers instead of failing with a conflict.
+	Force bool
+}
+
+// CreateMergePatch returns the JSON merge patch (RFC7386) which turns original into modified. It only
+// holds the fields which differ, so it doesn't overwrite the fields changed by others in between.
+// Custom resources don't support strategic merge patch, so lists, e.g. the finalizers, are always
+// replaced as a whole.
+func CreateMergePatch(original, modified *jinghzhuv1.Jinghzhu) ([]byte, error) {
+	originalJSON, err := json.Marshal(original)
+	if err != nil {
+		return nil, err
+	}
+	modifiedJSON, err := json.Marshal(modified)
+	if err != nil {
+		return nil, err
+	}
+
+	return jsonpatch.CreateMergePatch(originalJSON, modifiedJSON)
+}
+
+// MergePatch sends the metadata and spec fields which differ between original and modified as a JSON
+// merge patch. Status is left out, use MergePatchStatus for it.
+func (c *Client) MergePatch(name string, original, modified *jinghzhuv1.Jinghzhu) (*jinghzhuv1.Jinghzhu, error) {
+	return c.MergePatchWithContext(c.GetContext(), name, original, modified)
+}
+
+// MergePatchWithContext is MergePatch with the given context.
+func (c *Client) MergePatchWithContext(ctx context.Context, name string, original, modified *jinghzhuv1.Jinghzhu) (*jinghzhuv1.Jinghzhu, error) {
+	modified = modified.DeepCopy()
+	original.Status.DeepCopyInto(&modified.Status)
+	patch, err := CreateMergePatch(original, modified)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.PatchWithContext(ctx, name, apimachinerytypes.MergePatchType, patch)
+}
+
+// MergePatchStatus sends the status fields which differ between original and modified as a JSON merge
+// patch to the status subresource. A state change is checked and recorded like PatchStatus does. Only
+// then the patch holds metadata.resourceVersion of original as a precondition, so it fails with a
+// conflict if the instance has changed since original was read. Otherwise it applies on top of the
+// changes made by others in between.
+func (c *Client) MergePatchStatus(name string, original, modified *jinghzhuv1.Jinghzhu) (*jinghzhuv1.Jinghzhu, error) {
+	return c.MergePatchStatusWithContext(c.GetContext(), name, original, modified)
+}
+
+// MergePatchStatusWithContext is MergePatchStatus with the given context.
+func (c *Client) MergePatchStatusWithContext(ctx context.Context, name string, original, modified *jinghzhuv1.Jinghzhu) (*jinghzhuv1.Jinghzhu, error) {
+	status, err := nextStatus(original, &modified.Status)
+	if err != nil {
+		return nil, err
+	}
+	from, to := original.DeepCopy(), original.DeepCopy()
+	to.Status = *status
+	if original.Status.State != status.State {
+		// A resourceVersion in the patch is a precondition, so the transition can't race another one.
+		from.ResourceVersion = ""
+	}
+	patch, err := CreateMergePatch(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.PatchWithContext(ctx, name, apimachinerytypes.MergePatchType, patch, SubresourceStatus)
+}
+
+// Apply creates or updates the instance with server-side apply. The fields set in obj are owned by
+// opts.FieldManager, and the ones it owned before but are missing now are removed. Only the name,
+// labels, annotations, owner references and Spec.Desired are applied. The status is left to the
+// controller, and the deprecated Spec.Current and Spec.PodList aren't set. Like Create, it adds
+// finalizer jinghzhuv1.FinalizerPodCleanup.
+func (c *Client) Apply(obj *jinghzhuv1.Jinghzhu, opts ApplyOptions) (*jinghzhuv1.Jinghzhu, error) {
+	return c.ApplyWithContext(c.GetContext(), obj, opts)
+}
+
+// ApplyWithContext is Apply with the given context.
+func (c *Client) ApplyWithContext(ctx context.Context, obj *jinghzhuv1.Jinghzhu, opts ApplyOptions) (*jinghzhuv1.Jinghzhu, error) {
+	if opts.FieldManager == "" {
+		opts.FieldManager = DefaultFieldManager
+	}
+	patch, err := applyConfiguration(obj, c.namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.clientset.JinghzhuV1().Jinghzhus(c.namespace).Patch(ctx, obj.GetName(), apimachinerytypes.ApplyPatchType, patch,
+		metav1.PatchOptions{FieldManager: opts.FieldManager, Force: &opts.Force})
+}
+
+// applyConfiguration returns the fields of obj which Apply owns.
+func applyConfiguration(obj *jinghzhuv1.Jinghzhu, namespace string) ([]byte, error) {
+	metadata := metav1.ObjectMeta{
+		Name:            obj.GetName(),
+		Namespace:       namespace,
+		Labels:          obj.GetLabels(),
+		Annotations:     obj.GetAnnotations(),
+		OwnerReferences: obj.GetOwnerReferences(),
+		Finalizers:      []string{jinghzhuv1.FinalizerPodCleanup},
+	}
+	config := map[string]interface{}{
+		"apiVersion": jinghzhuv1.SchemeGroupVersion.String(),
+		"kind":       jinghzhuv1.Kind,
+		"metadata":   &metadata,
+		// Spec.Current and Spec.PodList aren't omitted when empty, so the spec is built by hand.
+		"spec": map[string]interface{}{
+			"desired": obj.Spec.Desired,
+		},
+	}
+
+	return json.Marshal(config)
+}
@@ -0,0 +1,133 @@
+package client
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	jinghzhuv1 "github.com/jinghzhu/KubernetesCRD/pkg/crd/jinghzhu/v1"
+	"github.com/jinghzhu/KubernetesCRD/pkg/types"
+	"k8s.io/apimachinery/pkg/runtime"
+	apimachinerytypes "k8s.io/apimachinery/pkg/types"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// patchBody returns the only patch sent to the fake clientset, after checking its type and subresource.
+func patchBody(t *testing.T, actions []k8stesting.PatchAction, patchType apimachinerytypes.PatchType, subresource string) map[string]interface{} {
+	t.Helper()
+	if len(actions) != 1 {
+		t.Fatalf("got %d patches, want 1", len(actions))
+	}
+	if actions[0].GetPatchType() != patchType || actions[0].GetSubresource() != subresource {
+		t.Errorf("patch is a %s of %q, want a %s of %q", actions[0].GetPatchType(), actions[0].GetSubresource(), patchType, subresource)
+	}
+	body := make(map[string]interface{})
+	if err := json.Unmarshal(actions[0].GetPatch(), &body); err != nil {
+		t.Fatal(err)
+	}
+
+	return body
+}
+
+func TestMergePatch(t *testing.T) {
+	original := newInstance("merge", nil, 1, types.StateRunning)
+	original.ResourceVersion = "3"
+	c, clientset := newFakeClient(original)
+	modified := original.DeepCopy()
+	modified.Labels = map[string]string{"tier": "web"}
+	modified.Spec.Desired = 3
+	// Left out of the patch.
+	modified.Status.Message = "changed"
+
+	got, err := c.MergePatch(original.Name, original, modified)
+	if err != nil {
+		t.Fatalf("MergePatch() error = %v", err)
+	}
+	want := map[string]interface{}{
+		"metadata": map[string]interface{}{"labels": map[string]interface{}{"tier": "web"}},
+		"spec":     map[string]interface{}{"desired": float64(3)},
+	}
+	if body := patchBody(t, patchActions(clientset), apimachinerytypes.MergePatchType, ""); !reflect.DeepEqual(body, want) {
+		t.Errorf("patch = %v, want %v", body, want)
+	}
+	if got.Spec.Desired != 3 || got.Labels["tier"] != "web" || got.Status.Message != "" {
+		t.Errorf("got %+v, want the spec and labels patched and the status left alone", got)
+	}
+}
+
+func TestMergePatchStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		state   types.State
+		message string
+		// wantStatus are the fields of the status in the patch, and wantMetadata is its metadata.
+		wantStatus   []string
+		wantMetadata map[string]interface{}
+	}{
+		{"message only", types.StateRunning, "2/2 Pods running", []string{"message"}, nil},
+		{"state changed", types.StateSucceeded, "done", []string{"history", "message", "state"},
+			map[string]interface{}{"resourceVersion": "3"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := newInstance("merge", nil, 1, types.StateRunning)
+			original.ResourceVersion = "3"
+			original.Status.Replicas = 1
+			c, clientset := newFakeClient(original)
+			modified := original.DeepCopy()
+			modified.Status.State = tt.state
+			modified.Status.Message = tt.message
+
+			got, err := c.MergePatchStatus(original.Name, original, modified)
+			if err != nil {
+				t.Fatalf("MergePatchStatus() error = %v", err)
+			}
+			body := patchBody(t, patchActions(clientset), apimachinerytypes.MergePatchType, SubresourceStatus)
+			if metadata, _ := body["metadata"].(map[string]interface{}); !reflect.DeepEqual(metadata, tt.wantMetadata) {
+				t.Errorf("patch metadata = %v, want %v", body["metadata"], tt.wantMetadata)
+			}
+			status, _ := body["status"].(map[string]interface{})
+			keys := make([]string, 0, len(status))
+			for _, key := range []string{"history", "message", "replicas", "state"} {
+				if _, ok := status[key]; ok {
+					keys = append(keys, key)
+				}
+			}
+			if len(status) != len(keys) || !reflect.DeepEqual(keys, tt.wantStatus) {
+				t.Errorf("patch status = %v, want only %v", status, tt.wantStatus)
+			}
+			if got.Status.State != tt.state || got.Status.Message != tt.message || got.Status.Replicas != 1 {
+				t.Errorf("status = %+v, want %s %q and the replicas kept", got.Status, tt.state, tt.message)
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	c, clientset := newFakeClient()
+	// The fake clientset doesn't support server-side apply, so the patch is only recorded.
+	clientset.PrependReactor("patch", jinghzhuv1.Plural, func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, &jinghzhuv1.Jinghzhu{}, nil
+	})
+	obj := newInstance("apply", map[string]string{"tier": "web"}, 2, types.StateRunning)
+	obj.Spec.PodList = []string{"apply-a"}
+
+	if _, err := c.Apply(obj, ApplyOptions{}); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	want := map[string]interface{}{
+		"apiVersion": jinghzhuv1.SchemeGroupVersion.String(),
+		"kind":       jinghzhuv1.Kind,
+		"metadata": map[string]interface{}{
+			"name":              "apply",
+			"namespace":         testNamespace,
+			"labels":            map[string]interface{}{"tier": "web"},
+			"finalizers":        []interface{}{jinghzhuv1.FinalizerPodCleanup},
+			"creationTimestamp": nil,
+		},
+		"spec": map[string]interface{}{"desired": float64(2)},
+	}
+	if body := patchBody(t, patchActions(clientset), apimachinerytypes.ApplyPatchType, ""); !reflect.DeepEqual(body, want) {
+		t.Errorf("patch = %v, want %v", body, want)
+	}
+}
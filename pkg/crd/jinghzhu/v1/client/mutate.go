@@ -0,0 +1,73 @@
+package client
+
+import (
+	"context"
+	"reflect"
+
+	jinghzhuv1 "github.com/jinghzhu/KubernetesCRD/pkg/crd/jinghzhu/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+)
+
+// MutateFunc changes the given CRD instance in place. If it returns an error, nothing is written and
+// the error is returned by Mutate or MutateStatus.
+type MutateFunc func(instance *jinghzhuv1.Jinghzhu) error
+
+// Mutate reads the latest CRD instance, lets mutate change it and updates it. On a conflict, i.e. the
+// instance was changed by someone else in between, it starts over with backoff, so mutate may be called
+// several times and must only depend on the instance it gets. If mutate changes nothing, nothing is
+// written and the latest instance is returned. Changes to the status are ignored, use MutateStatus.
+func (c *Client) Mutate(ctx context.Context, name string, mutate MutateFunc) (*jinghzhuv1.Jinghzhu, error) {
+	return c.mutate(ctx, name, mutate, false)
+}
+
+// MutateStatus is Mutate for the status subresource. Changes outside of the status are ignored. A state
+// change is checked and recorded like PatchStatus does.
+func (c *Client) MutateStatus(ctx context.Context, name string, mutate MutateFunc) (*jinghzhuv1.Jinghzhu, error) {
+	return c.mutate(ctx, name, mutate, true)
+}
+
+func (c *Client) mutate(ctx context.Context, name string, mutate MutateFunc, status bool) (*jinghzhuv1.Jinghzhu, error) {
+	var result *jinghzhuv1.Jinghzhu
+	err := retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		latest, err := c.GetWithContext(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		updated := latest.DeepCopy()
+		if err = mutate(updated); err != nil {
+			return err
+		}
+
+		if !status {
+			latest.Status.DeepCopyInto(&updated.Status)
+			if reflect.DeepEqual(latest, updated) {
+				result = latest
+
+				return nil
+			}
+			result, err = c.UpdateWithContext(ctx, updated, metav1.UpdateOptions{})
+
+			return err
+		}
+
+		if reflect.DeepEqual(latest.Status, updated.Status) {
+			result = latest
+
+			return nil
+		}
+		next, err := nextStatus(latest, &updated.Status)
+		if err != nil {
+			return err
+		}
+		latest.Status = *next
+		result, err = c.UpdateStatusWithContext(ctx, latest, metav1.UpdateOptions{})
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
@@ -0,0 +1,293 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	jinghzhuv1 "github.com/jinghzhu/KubernetesCRD/pkg/crd/jinghzhu/v1"
+	"github.com/jinghzhu/KubernetesCRD/pkg/types"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	apimachinerytypes "k8s.io/apimachinery/pkg/types"
+	errors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+const (
+	// FieldName and FieldNamespace are the only fields the API server can select CRD instances by.
+	FieldName      string = "metadata.name"
+	FieldNamespace string = "metadata.namespace"
+)
+
+// Query selects CRD instances. The label and field selectors are sent to the API server, and the
+// predicates, e.g. on Status.State or Spec.Desired, are checked by the client on what it gets back.
+// The conditions are ANDed.
+//
+//	query := NewQuery().
+//		LabelIn("tier", "web", "api").
+//		HasLabel("team").
+//		State(types.StatePending, types.StateRunning).
+//		DesiredAtLeast(3)
+//	list, err := c.ListQuery(ctx, query)
+type Query struct {
+	labelSelector labels.Selector
+	fieldSelector []fields.Selector
+	predicates    []Predicate
+	errs          []error
+}
+
+// NewQuery returns a Query which selects all CRD instances.
+func NewQuery() *Query {
+	return &Query{labelSelector: labels.Everything()}
+}
+
+// WithLabel selects the instances with label key set to value.
+func (q *Query) WithLabel(key, value string) *Query {
+	return q.label(key, selection.Equals, value)
+}
+
+// WithoutLabel selects the instances without label key set to value, including the ones without it.
+func (q *Query) WithoutLabel(key, value string) *Query {
+	return q.label(key, selection.NotEquals, value)
+}
+
+// LabelIn selects the instances with label key set to one of values.
+func (q *Query) LabelIn(key string, values ...string) *Query {
+	return q.label(key, selection.In, values...)
+}
+
+// LabelNotIn selects the instances without label key set to any of values, including the ones
+// without it.
+func (q *Query) LabelNotIn(key string, values ...string) *Query {
+	return q.label(key, selection.NotIn, values...)
+}
+
+// HasLabel selects the instances with label key, whatever its value is.
+func (q *Query) HasLabel(key string) *Query {
+	return q.label(key, selection.Exists)
+}
+
+// LacksLabel selects the instances without label key.
+func (q *Query) LacksLabel(key string) *Query {
+	return q.label(key, selection.DoesNotExist)
+}
+
+func (q *Query) label(key string, op selection.Operator, values ...string) *Query {
+	requirement, err := labels.NewRequirement(key, op, values)
+	if err != nil {
+		q.errs = append(q.errs, err)
+
+		return q
+	}
+	q.labelSelector = q.labelSelector.Add(*requirement)
+
+	return q
+}
+
+// Name selects the instance with the given name.
+func (q *Query) Name(name string) *Query {
+	q.fieldSelector = append(q.fieldSelector, fields.OneTermEqualSelector(FieldName, name))
+
+	return q
+}
+
+// Namespace selects the instances in the given namespace. The client only sees its own namespace, so
+// any other one selects nothing.
+func (q *Query) Namespace(namespace string) *Query {
+	q.fieldSelector = append(q.fieldSelector, fields.OneTermEqualSelector(FieldNamespace, namespace))
+
+	return q
+}
+
+// State selects the instances in one of the given states. It is checked by the client.
+func (q *Query) State(states ...types.State) *Query {
+	return q.Where(func(instance *jinghzhuv1.Jinghzhu) (bool, error) {
+		for _, state := range states {
+			if instance.Status.State == state {
+				return true, nil
+			}
+		}
+
+		return false, nil
+	})
+}
+
+// DesiredAtLeast selects the instances which desire min Pods or more. It is checked by the client.
+func (q *Query) DesiredAtLeast(min int) *Query {
+	return q.Where(func(instance *jinghzhuv1.Jinghzhu) (bool, error) {
+		return instance.Spec.Desired >= min, nil
+	})
+}
+
+// DesiredAtMost selects the instances which desire max Pods or fewer. It is checked by the client.
+func (q *Query) DesiredAtMost(max int) *Query {
+	return q.Where(func(instance *jinghzhuv1.Jinghzhu) (bool, error) {
+		return instance.Spec.Desired <= max, nil
+	})
+}
+
+// Where selects the instances for which predicate is true. It is checked by the client.
+func (q *Query) Where(predicate Predicate) *Query {
+	q.predicates = append(q.predicates, predicate)
+
+	return q
+}
+
+// ListOptions returns the list options with the label and field selectors of the query, for both List
+// and Watch. The predicates need to be checked with Matches on the result.
+func (q *Query) ListOptions() (metav1.ListOptions, error) {
+	if len(q.errs) > 0 {
+		return metav1.ListOptions{}, errors.NewAggregate(q.errs)
+	}
+	opts := metav1.ListOptions{LabelSelector: q.labelSelector.String()}
+	if len(q.fieldSelector) > 0 {
+		opts.FieldSelector = fields.AndSelectors(q.fieldSelector...).String()
+	}
+
+	return opts, nil
+}
+
+// Matches returns whether the instance matches the whole query, selectors included.
+func (q *Query) Matches(instance *jinghzhuv1.Jinghzhu) (bool, error) {
+	if !q.labelSelector.Matches(labels.Set(instance.GetLabels())) {
+		return false, nil
+	}
+	fieldSet := fields.Set{FieldName: instance.GetName(), FieldNamespace: instance.GetNamespace()}
+	for _, selector := range q.fieldSelector {
+		if !selector.Matches(fieldSet) {
+			return false, nil
+		}
+	}
+	for _, predicate := range q.predicates {
+		ok, err := predicate(instance)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// ListQuery returns the CRD instances which match the query. The list metadata, e.g. the
+// resourceVersion, is the one of the API server. Pass the list to WatchQuery to watch from there.
+func (c *Client) ListQuery(ctx context.Context, query *Query) (*jinghzhuv1.JinghzhuList, error) {
+	opts, err := query.ListOptions()
+	if err != nil {
+		return nil, err
+	}
+	list, err := c.ListWithContext(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]jinghzhuv1.Jinghzhu, 0, len(list.Items))
+	for i := range list.Items {
+		ok, err := query.Matches(&list.Items[i])
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			items = append(items, list.Items[i])
+		}
+	}
+	list.Items = items
+
+	return list, nil
+}
+
+// WatchQuery watches the CRD instances which match the query, starting right after list, which is
+// normally the result of ListQuery. The events look as if the predicates were checked by the API server:
+// an instance which stops matching them is sent as Deleted, and one which starts matching them is sent
+// as Added. The instances in list are the ones the receiver is taken to know. To watch from a
+// resourceVersion without having listed, pass a list with only that resourceVersion. Then the instances
+// are sent as Added once they change while matching. A nil list is an empty one, i.e. the watch starts
+// at the latest resourceVersion with nothing known.
+// If a predicate fails, the event is replaced by a watch.Error event. Its Object is the *metav1.Status
+// of an InternalError with the name of the instance in its details and the message of the error of the
+// predicate. apierrors.FromObject turns it back into an error.
+func (c *Client) WatchQuery(ctx context.Context, query *Query, list *jinghzhuv1.JinghzhuList) (watch.Interface, error) {
+	opts, err := query.ListOptions()
+	if err != nil {
+		return nil, err
+	}
+	if list == nil {
+		list = &jinghzhuv1.JinghzhuList{}
+	}
+	opts.ResourceVersion = list.GetResourceVersion()
+	w, err := c.clientset.JinghzhuV1().Jinghzhus(c.namespace).Watch(ctx, opts)
+	if err != nil || len(query.predicates) == 0 {
+		return w, err
+	}
+
+	known := make(map[apimachinerytypes.UID]bool, len(list.Items))
+	for i := range list.Items {
+		known[list.Items[i].GetUID()] = true
+	}
+
+	return watch.Filter(w, func(event watch.Event) (watch.Event, bool) {
+		instance, ok := event.Object.(*jinghzhuv1.Jinghzhu)
+		if !ok || event.Type == watch.Bookmark {
+			return event, true
+		}
+		uid := instance.GetUID()
+		eventType, err := query.eventType(event.Type, instance, known[uid])
+		if err != nil {
+			status := apierrors.NewInternalError(err).ErrStatus
+			status.Details.Name = instance.GetName()
+			status.Details.Kind = jinghzhuv1.Kind
+
+			return watch.Event{Type: watch.Error, Object: &status}, true
+		}
+		switch eventType {
+		case "":
+			return event, false
+		case watch.Deleted:
+			delete(known, uid)
+		default:
+			known[uid] = true
+		}
+		event.Type = eventType
+
+		return event, true
+	}), nil
+}
+
+// MatchError is returned when a predicate of a Query fails on an instance.
+type MatchError struct {
+	Namespace string
+	Name      string
+	Err       error
+}
+
+func (e *MatchError) Error() string {
+	return fmt.Sprintf("fail to match Jinghzhu %s/%s: %v", e.Namespace, e.Name, e.Err)
+}
+
+func (e *MatchError) Unwrap() error {
+	return e.Err
+}
+
+// eventType returns the type of the event of a raw watch as the receiver of a filtered watch sees it,
+// or "" if the receiver doesn't get it. known tells whether the receiver has got the instance before.
+// If a predicate fails, the error is *MatchError.
+func (q *Query) eventType(eventType watch.EventType, instance *jinghzhuv1.Jinghzhu, known bool) (watch.EventType, error) {
+	match := false
+	if eventType != watch.Deleted {
+		var err error
+		if match, err = q.Matches(instance); err != nil {
+			return "", &MatchError{Namespace: instance.GetNamespace(), Name: instance.GetName(), Err: err}
+		}
+	}
+	switch {
+	case !match && known:
+		return watch.Deleted, nil
+	case !match:
+		return "", nil
+	case !known:
+		return watch.Added, nil
+	}
+
+	return watch.Modified, nil
+}
@@ -0,0 +1,243 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	jinghzhuv1 "github.com/jinghzhu/KubernetesCRD/pkg/crd/jinghzhu/v1"
+	jinghzhuv1fake "github.com/jinghzhu/KubernetesCRD/pkg/crd/jinghzhu/v1/apis/clientset/versioned/fake"
+	"github.com/jinghzhu/KubernetesCRD/pkg/types"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	apimachinerytypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+const (
+	testNamespace string = "crd"
+)
+
+func newInstance(name string, labels map[string]string, desired int, state types.State) *jinghzhuv1.Jinghzhu {
+	return &jinghzhuv1.Jinghzhu{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: testNamespace,
+			UID:       apimachinerytypes.UID(name + "-uid"),
+			Labels:    labels,
+		},
+		Spec:   jinghzhuv1.JinghzhuSpec{Desired: desired},
+		Status: jinghzhuv1.JinghzhuStatus{State: state},
+	}
+}
+
+func TestQueryMatches(t *testing.T) {
+	web := newInstance("web", map[string]string{"tier": "web", "team": "a"}, 3, types.StateRunning)
+	errFail := fmt.Errorf("fail")
+	tests := []struct {
+		name    string
+		query   *Query
+		want    bool
+		wantErr bool
+	}{
+		{"everything", NewQuery(), true, false},
+		{"with label", NewQuery().WithLabel("tier", "web"), true, false},
+		{"with other label value", NewQuery().WithLabel("tier", "api"), false, false},
+		{"without label", NewQuery().WithoutLabel("tier", "api"), true, false},
+		{"label in", NewQuery().LabelIn("tier", "api", "web"), true, false},
+		{"label not in", NewQuery().LabelNotIn("tier", "api", "web"), false, false},
+		{"has label", NewQuery().HasLabel("team"), true, false},
+		{"lacks label", NewQuery().LacksLabel("team"), false, false},
+		{"name", NewQuery().Name("web"), true, false},
+		{"other name", NewQuery().Name("api"), false, false},
+		{"namespace", NewQuery().Namespace(testNamespace), true, false},
+		{"other namespace", NewQuery().Namespace("default"), false, false},
+		{"state", NewQuery().State(types.StatePending, types.StateRunning), true, false},
+		{"other state", NewQuery().State(types.StatePending), false, false},
+		{"desired at least", NewQuery().DesiredAtLeast(3), true, false},
+		{"desired at least more", NewQuery().DesiredAtLeast(4), false, false},
+		{"desired at most", NewQuery().DesiredAtMost(3), true, false},
+		{"desired at most less", NewQuery().DesiredAtMost(2), false, false},
+		{"all conditions", NewQuery().WithLabel("tier", "web").Name("web").State(types.StateRunning).DesiredAtLeast(1), true, false},
+		{"one condition fails", NewQuery().WithLabel("tier", "web").Name("web").State(types.StatePending), false, false},
+		{"predicate error", NewQuery().Where(func(*jinghzhuv1.Jinghzhu) (bool, error) { return false, errFail }), false, true},
+		{"selector checked before predicates", NewQuery().Name("api").Where(func(*jinghzhuv1.Jinghzhu) (bool, error) { return false, errFail }), false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.query.Matches(web)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Matches() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueryListOptions(t *testing.T) {
+	opts, err := NewQuery().WithLabel("tier", "web").HasLabel("team").Name("web").State(types.StateRunning).ListOptions()
+	if err != nil {
+		t.Fatalf("ListOptions() error = %v", err)
+	}
+	if opts.LabelSelector != "team,tier=web" {
+		t.Errorf("label selector = %q, want team,tier=web", opts.LabelSelector)
+	}
+	if opts.FieldSelector != FieldName+"=web" {
+		t.Errorf("field selector = %q, want %s=web", opts.FieldSelector, FieldName)
+	}
+
+	if _, err = NewQuery().WithLabel("invalid key!", "web").ListOptions(); err == nil {
+		t.Error("ListOptions() succeeded, want the invalid label key to fail")
+	}
+}
+
+func TestQueryEventType(t *testing.T) {
+	query := NewQuery().State(types.StateRunning)
+	running := newInstance("a", nil, 1, types.StateRunning)
+	pending := newInstance("a", nil, 1, types.StatePending)
+	tests := []struct {
+		name      string
+		eventType watch.EventType
+		instance  *jinghzhuv1.Jinghzhu
+		known     bool
+		want      watch.EventType
+	}{
+		{"added matching", watch.Added, running, false, watch.Added},
+		{"added not matching", watch.Added, pending, false, ""},
+		{"modified starts matching", watch.Modified, running, false, watch.Added},
+		{"modified still matching", watch.Modified, running, true, watch.Modified},
+		{"modified stops matching", watch.Modified, pending, true, watch.Deleted},
+		{"modified never matching", watch.Modified, pending, false, ""},
+		{"deleted known", watch.Deleted, running, true, watch.Deleted},
+		{"deleted unknown", watch.Deleted, running, false, ""},
+		{"added known", watch.Added, running, true, watch.Modified},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := query.eventType(tt.eventType, tt.instance, tt.known)
+			if err != nil {
+				t.Fatalf("eventType() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("eventType() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	failing := NewQuery().Where(func(*jinghzhuv1.Jinghzhu) (bool, error) { return false, fmt.Errorf("fail") })
+	_, err := failing.eventType(watch.Modified, running, true)
+	if matchErr, ok := err.(*MatchError); !ok || matchErr.Name != "a" || matchErr.Namespace != testNamespace {
+		t.Errorf("eventType() error = %v, want *MatchError for %s/a", err, testNamespace)
+	}
+	if eventType, err := failing.eventType(watch.Deleted, running, true); err != nil || eventType != watch.Deleted {
+		t.Errorf("eventType() = %q, %v, want the deletion without checking the predicates", eventType, err)
+	}
+}
+
+func TestWatchQuery(t *testing.T) {
+	ctx := context.Background()
+	listed := newInstance("listed", nil, 1, types.StateRunning)
+	clientset := jinghzhuv1fake.NewSimpleClientset(listed)
+	c := NewClientForClientset(ctx, clientset, testNamespace)
+	query := NewQuery().State(types.StateRunning).Where(func(instance *jinghzhuv1.Jinghzhu) (bool, error) {
+		if instance.Spec.Desired < 0 {
+			return false, fmt.Errorf("negative desired")
+		}
+
+		return true, nil
+	})
+	list, err := c.ListQuery(ctx, query)
+	if err != nil {
+		t.Fatalf("ListQuery() error = %v", err)
+	}
+	w, err := c.WatchQuery(ctx, query, list)
+	if err != nil {
+		t.Fatalf("WatchQuery() error = %v", err)
+	}
+	defer w.Stop()
+
+	instances := clientset.JinghzhuV1().Jinghzhus(testNamespace)
+	// The listed instance is known, so it is Modified rather than Added.
+	updated := listed.DeepCopy()
+	updated.Spec.Desired = 2
+	if _, err = instances.Update(ctx, updated, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = instances.Create(ctx, newInstance("pending", nil, 1, types.StatePending), metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = instances.Create(ctx, newInstance("running", nil, 1, types.StateRunning), metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	updated = updated.DeepCopy()
+	updated.Status.State = types.StatePending
+	if _, err = instances.Update(ctx, updated, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = instances.Create(ctx, newInstance("invalid", nil, -1, types.StateRunning), metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		eventType watch.EventType
+		name      string
+	}{
+		{watch.Modified, "listed"},
+		{watch.Added, "running"},
+		{watch.Deleted, "listed"},
+		{watch.Error, ""},
+	}
+	for _, expected := range want {
+		select {
+		case event := <-w.ResultChan():
+			if event.Type != expected.eventType {
+				t.Fatalf("got %s event %+v, want %s", event.Type, event.Object, expected.eventType)
+			}
+			if expected.eventType == watch.Error {
+				status, ok := event.Object.(*metav1.Status)
+				if !ok {
+					t.Fatalf("error event has %T, want *metav1.Status", event.Object)
+				}
+				if err = apierrors.FromObject(status); !apierrors.IsInternalError(err) || status.Details.Name != "invalid" {
+					t.Errorf("error event is %v of %+v, want an InternalError of invalid", err, status.Details)
+				}
+
+				continue
+			}
+			if name := event.Object.(*jinghzhuv1.Jinghzhu).GetName(); name != expected.name {
+				t.Errorf("got %s event of %s, want %s", event.Type, name, expected.name)
+			}
+		case <-time.After(wait.ForeverTestTimeout):
+			t.Fatalf("timed out waiting for the %s event of %s", expected.eventType, expected.name)
+		}
+	}
+}
+
+func TestWatchQueryNilList(t *testing.T) {
+	ctx := context.Background()
+	c, clientset := newFakeClient(newInstance("existing", nil, 1, types.StateRunning))
+
+	w, err := c.WatchQuery(ctx, NewQuery().State(types.StateRunning), nil)
+	if err != nil {
+		t.Fatalf("WatchQuery() error = %v", err)
+	}
+	defer w.Stop()
+
+	// Nothing is known, so a matching change is Added.
+	existing := newInstance("existing", nil, 2, types.StateRunning)
+	if _, err = clientset.JinghzhuV1().Jinghzhus(testNamespace).Update(ctx, existing, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case event := <-w.ResultChan():
+		if event.Type != watch.Added || event.Object.(*jinghzhuv1.Jinghzhu).GetName() != existing.Name {
+			t.Errorf("got %s event %+v, want existing added", event.Type, event.Object)
+		}
+	case <-time.After(wait.ForeverTestTimeout):
+		t.Fatal("timed out waiting for the event")
+	}
+}
@@ -2,11 +2,16 @@ package client
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"sync"
 
 	jinghzhuv1 "github.com/jinghzhu/KubernetesCRD/pkg/crd/jinghzhu/v1"
 	jinghzhuv1apisclientset "github.com/jinghzhu/KubernetesCRD/pkg/crd/jinghzhu/v1/apis/clientset/versioned"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	apimachinerytypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
 
 	"github.com/jinghzhu/KubernetesCRD/pkg/config"
 	"github.com/jinghzhu/KubernetesCRD/pkg/types"
@@ -14,8 +19,15 @@ import (
 )
 
 const (
-	PatchJSONTypeReplace string = "replace"
 	PatchJSONTypeAdd     string = "add"
+	PatchJSONTypeRemove  string = "remove"
+	PatchJSONTypeReplace string = "replace"
+	PatchJSONTypeMove    string = "move"
+	PatchJSONTypeCopy    string = "copy"
+	PatchJSONTypeTest    string = "test"
+
+	// SubresourceStatus is the name of the status subresource of Jinghzhu.
+	SubresourceStatus string = "status"
 )
 
 var (
@@ -24,32 +36,119 @@ var (
 	validPatchResources         map[string]string
 )
 
-// Client is an API client to help perform CRUD for CRD instances.
+// JinghzhuClient is the interface of the API client for CRD Jinghzhu v1. Depend on it instead of *Client
+// so the code can be tested with the fake client in package client/fake.
+type JinghzhuClient interface {
+	GetNamespace() string
+	GetPlural() string
+	GetContext() context.Context
+
+	Create(obj *jinghzhuv1.Jinghzhu, opts metav1.CreateOptions) (*jinghzhuv1.Jinghzhu, error)
+	CreateWithContext(ctx context.Context, obj *jinghzhuv1.Jinghzhu, opts metav1.CreateOptions) (*jinghzhuv1.Jinghzhu, error)
+	CreateDefault(obj *jinghzhuv1.Jinghzhu) (*jinghzhuv1.Jinghzhu, error)
+	Update(obj *jinghzhuv1.Jinghzhu, opts metav1.UpdateOptions) (*jinghzhuv1.Jinghzhu, error)
+	UpdateWithContext(ctx context.Context, obj *jinghzhuv1.Jinghzhu, opts metav1.UpdateOptions) (*jinghzhuv1.Jinghzhu, error)
+	UpdateDefault(obj *jinghzhuv1.Jinghzhu) (*jinghzhuv1.Jinghzhu, error)
+	UpdateStatus(obj *jinghzhuv1.Jinghzhu, opts metav1.UpdateOptions) (*jinghzhuv1.Jinghzhu, error)
+	UpdateStatusWithContext(ctx context.Context, obj *jinghzhuv1.Jinghzhu, opts metav1.UpdateOptions) (*jinghzhuv1.Jinghzhu, error)
+	UpdateSpecAndStatus(name string, jinghzhuSpec *jinghzhuv1.JinghzhuSpec, jinghzhuStatus *jinghzhuv1.JinghzhuStatus) (*jinghzhuv1.Jinghzhu, error)
+	UpdateSpecAndStatusWithContext(ctx context.Context, name string, jinghzhuSpec *jinghzhuv1.JinghzhuSpec, jinghzhuStatus *jinghzhuv1.JinghzhuStatus) (*jinghzhuv1.Jinghzhu, error)
+	Patch(name string, pt apimachinerytypes.PatchType, data []byte, subresources ...string) (*jinghzhuv1.Jinghzhu, error)
+	PatchWithContext(ctx context.Context, name string, pt apimachinerytypes.PatchType, data []byte, subresources ...string) (*jinghzhuv1.Jinghzhu, error)
+	PatchJSONType(name string, ops []PatchJSONTypeOps, subresources ...string) (*jinghzhuv1.Jinghzhu, error)
+	PatchJSONTypeWithContext(ctx context.Context, name string, ops []PatchJSONTypeOps, subresources ...string) (*jinghzhuv1.Jinghzhu, error)
+	PatchWithBuilder(name string, builder *JSONPatchBuilder, subresources ...string) (*jinghzhuv1.Jinghzhu, error)
+	PatchWithBuilderWithContext(ctx context.Context, name string, builder *JSONPatchBuilder, subresources ...string) (*jinghzhuv1.Jinghzhu, error)
+	Mutate(ctx context.Context, name string, mutate MutateFunc) (*jinghzhuv1.Jinghzhu, error)
+	MutateStatus(ctx context.Context, name string, mutate MutateFunc) (*jinghzhuv1.Jinghzhu, error)
+	MergePatch(name string, original, modified *jinghzhuv1.Jinghzhu) (*jinghzhuv1.Jinghzhu, error)
+	MergePatchWithContext(ctx context.Context, name string, original, modified *jinghzhuv1.Jinghzhu) (*jinghzhuv1.Jinghzhu, error)
+	MergePatchStatus(name string, original, modified *jinghzhuv1.Jinghzhu) (*jinghzhuv1.Jinghzhu, error)
+	MergePatchStatusWithContext(ctx context.Context, name string, original, modified *jinghzhuv1.Jinghzhu) (*jinghzhuv1.Jinghzhu, error)
+	Apply(obj *jinghzhuv1.Jinghzhu, opts ApplyOptions) (*jinghzhuv1.Jinghzhu, error)
+	ApplyWithContext(ctx context.Context, obj *jinghzhuv1.Jinghzhu, opts ApplyOptions) (*jinghzhuv1.Jinghzhu, error)
+	PatchSpec(name string, jinghzhuSpec *jinghzhuv1.JinghzhuSpec) (*jinghzhuv1.Jinghzhu, error)
+	PatchSpecWithContext(ctx context.Context, name string, jinghzhuSpec *jinghzhuv1.JinghzhuSpec) (*jinghzhuv1.Jinghzhu, error)
+	PatchStatus(name string, jinghzhuStatus *jinghzhuv1.JinghzhuStatus) (*jinghzhuv1.Jinghzhu, error)
+	PatchStatusWithContext(ctx context.Context, name string, jinghzhuStatus *jinghzhuv1.JinghzhuStatus) (*jinghzhuv1.Jinghzhu, error)
+	PatchSpecAndStatus(name string, jinghzhuSpec *jinghzhuv1.JinghzhuSpec, jinghzhuStatus *jinghzhuv1.JinghzhuStatus) (*jinghzhuv1.Jinghzhu, error)
+	PatchSpecAndStatusWithContext(ctx context.Context, name string, jinghzhuSpec *jinghzhuv1.JinghzhuSpec, jinghzhuStatus *jinghzhuv1.JinghzhuStatus) (*jinghzhuv1.Jinghzhu, error)
+	GetScale(name string, opts metav1.GetOptions) (*autoscalingv1.Scale, error)
+	GetScaleWithContext(ctx context.Context, name string, opts metav1.GetOptions) (*autoscalingv1.Scale, error)
+	UpdateScale(name string, scale *autoscalingv1.Scale, opts metav1.UpdateOptions) (*autoscalingv1.Scale, error)
+	UpdateScaleWithContext(ctx context.Context, name string, scale *autoscalingv1.Scale, opts metav1.UpdateOptions) (*autoscalingv1.Scale, error)
+	Delete(name string, opts metav1.DeleteOptions) error
+	DeleteWithContext(ctx context.Context, name string, opts metav1.DeleteOptions) error
+	DeleteDefault(name string) error
+	ForceDelete(name string, opts metav1.DeleteOptions) error
+	ForceDeleteWithContext(ctx context.Context, name string, opts metav1.DeleteOptions) error
+	Get(name string, opts metav1.GetOptions) (*jinghzhuv1.Jinghzhu, error)
+	GetWithContext(ctx context.Context, name string, opts metav1.GetOptions) (*jinghzhuv1.Jinghzhu, error)
+	GetDefault(name string) (*jinghzhuv1.Jinghzhu, error)
+	List(opts metav1.ListOptions) (*jinghzhuv1.JinghzhuList, error)
+	ListWithContext(ctx context.Context, opts metav1.ListOptions) (*jinghzhuv1.JinghzhuList, error)
+	ListDefaultDefault() (*jinghzhuv1.JinghzhuList, error)
+	ListEach(ctx context.Context, opts metav1.ListOptions, fn func(instance *jinghzhuv1.Jinghzhu) error) error
+	ListStream(ctx context.Context, opts metav1.ListOptions) (<-chan *jinghzhuv1.Jinghzhu, <-chan error)
+	ListQuery(ctx context.Context, query *Query) (*jinghzhuv1.JinghzhuList, error)
+	WatchQuery(ctx context.Context, query *Query, list *jinghzhuv1.JinghzhuList) (watch.Interface, error)
+	Watch(ctx context.Context, query *Query, resourceVersion string) (<-chan WatchEvent, <-chan error)
+
+	WaitFor(ctx context.Context, name string, predicate Predicate) (*jinghzhuv1.Jinghzhu, error)
+	WaitForState(ctx context.Context, name string, state types.State) (*jinghzhuv1.Jinghzhu, error)
+	WaitForCondition(ctx context.Context, name, conditionType string, status metav1.ConditionStatus) (*jinghzhuv1.Jinghzhu, error)
+	WaitForDesiredReached(ctx context.Context, name string) (*jinghzhuv1.Jinghzhu, error)
+	WaitForInstanceProcessed(ctx context.Context, name string) (*jinghzhuv1.Jinghzhu, error)
+	WaitForDeletion(ctx context.Context, name string) error
+}
+
+var _ JinghzhuClient = &Client{}
+
+// Client is an API client to help perform CRUD for CRD instances. The methods which wrap an API call come
+// in pairs: one which uses the context given to NewClient, and a variant with the suffix WithContext which
+// takes a per-call context. The methods which build on top of them, because they retry, page, filter,
+// watch or wait, i.e. Mutate, MutateStatus, ListEach, ListStream, ListQuery, WatchQuery, Watch and the
+// WaitFor methods, only take a context as their first argument. They may run for long, so the caller
+// always has to decide when they give up.
 type Client struct {
-	clientset *jinghzhuv1apisclientset.Clientset
+	clientset jinghzhuv1apisclientset.Interface
 	namespace string
 	plural    string
 	ctx       context.Context
 }
 
 // PatchJSONTypeOps describes the operations for PATCH defined in RFC6902. https://tools.ietf.org/html/rfc6902
-// The supported operations are: add, remove, replace, move, copy and test.
+// The supported operations are: add, remove, replace, move, copy and test. From is only used by move and
+// copy, and Value isn't sent for remove, move and copy.
 // When we news a Jinghzhu instance, we'll set default value for all fields. So, when you want to patch a Jinghzhu,
 // DO NOT use remove. Please use replace, even if you want to keep that field "empty".
-// Example:
-// 	things := make([]IntThingSpec, 2)
-// 	things[0].Op = "replace"
-// 	things[0].Path = "/status/message"
-// 	things[0].Value = "1234"
-// 	things[1].Op = "replace"
-// 	things[1].Path = "/status/state"
-// 	things[1].Value = ""
+// Prefer JSONPatchBuilder, which escapes and validates the paths, to building the slice by hand:
+// 	ops, err := NewJSONPatchBuilder().
+// 		Test("/status/state", types.StatePending).
+// 		Replace("/status/message", "1234").
+// 		Build()
 type PatchJSONTypeOps struct {
 	Op    string      `json:"op"`
+	From  string      `json:"from,omitempty"`
 	Path  string      `json:"path"`
 	Value interface{} `json:"value"`
 }
 
+// MarshalJSON leaves out the value of the operations which don't take one.
+func (o PatchJSONTypeOps) MarshalJSON() ([]byte, error) {
+	switch o.Op {
+	case PatchJSONTypeRemove, PatchJSONTypeMove, PatchJSONTypeCopy:
+		return json.Marshal(struct {
+			Op   string `json:"op"`
+			From string `json:"from,omitempty"`
+			Path string `json:"path"`
+		}{Op: o.Op, From: o.From, Path: o.Path})
+	}
+	type ops PatchJSONTypeOps
+
+	return json.Marshal(ops(o))
+}
+
 // GetNamespace returns the namespace the client talks to.
 func (c *Client) GetNamespace() string {
 	return c.namespace
@@ -60,7 +159,7 @@ func (c *Client) GetPlural() string {
 	return c.plural
 }
 
-// GetContext returns the context of client.
+// GetContext returns the default context of client, which is used by the methods without a context.
 func (c *Client) GetContext() context.Context {
 	return c.ctx
 }
@@ -87,14 +186,19 @@ func NewClient(ctx context.Context, kubeconfigPath, namespace string) (*Client,
 
 		return nil, err
 	}
-	c := &Client{
+
+	return NewClientForClientset(ctx, clientset, namespace), nil
+}
+
+// NewClientForClientset returns the API client for CRD Jinghzhu v1 on top of the given clientset, e.g. the
+// fake one from package versioned/fake.
+func NewClientForClientset(ctx context.Context, clientset jinghzhuv1apisclientset.Interface, namespace string) *Client {
+	return &Client{
 		clientset: clientset,
 		namespace: namespace,
 		plural:    jinghzhuv1.Plural,
 		ctx:       ctx,
 	}
-
-	return c, nil
 }
 
 // GetDefaultClient returns an API client interface for CRD Jinghzhu v1. It assumes the kubeconfig
@@ -110,7 +214,7 @@ func GetDefaultClient() *Client {
 			clientset: clientset,
 			namespace: cfg.GetCRDNamespace(),
 			plural:    jinghzhuv1.Plural,
-			ctx:       types.GetCtx(),
+			ctx:       context.Background(),
 		}
 	})
 
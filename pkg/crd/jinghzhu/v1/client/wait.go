@@ -0,0 +1,189 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	jinghzhuv1 "github.com/jinghzhu/KubernetesCRD/pkg/crd/jinghzhu/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+	watchtools "k8s.io/client-go/tools/watch"
+
+	"github.com/jinghzhu/KubernetesCRD/pkg/types"
+)
+
+// Predicate tells whether a Jinghzhu instance has reached the state a caller waits for, or whether it
+// matches a Query.
+type Predicate func(instance *jinghzhuv1.Jinghzhu) (bool, error)
+
+// WaitTimeoutError is returned when the context of a wait is done before its predicate is satisfied.
+type WaitTimeoutError struct {
+	Namespace string
+	Name      string
+	// Last is the last observed instance. It is nil if the instance was never observed.
+	Last *jinghzhuv1.Jinghzhu
+	// Err is the error of the context, i.e. context.DeadlineExceeded or context.Canceled.
+	Err error
+}
+
+func (e *WaitTimeoutError) Error() string {
+	if e.Last == nil {
+		return fmt.Sprintf("timed out waiting for Jinghzhu %s/%s: %v", e.Namespace, e.Name, e.Err)
+	}
+
+	return fmt.Sprintf("timed out waiting for Jinghzhu %s/%s: %v, last observed state = %q, desired = %d, ready = %d",
+		e.Namespace, e.Name, e.Err, e.Last.Status.State, e.Last.Spec.Desired, e.Last.Status.Replicas)
+}
+
+func (e *WaitTimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// WaitFor watches the CRD instance until predicate returns true, and returns the instance at that point.
+// The watch resumes from the last seen resourceVersion when it is closed by the server, and starts over
+// from a fresh Get if that resourceVersion is too old. If ctx is done first, the error is *WaitTimeoutError.
+// If the instance is deleted while waiting, the error is a NotFound API error.
+func (c *Client) WaitFor(ctx context.Context, name string, predicate Predicate) (*jinghzhuv1.Jinghzhu, error) {
+	var result *jinghzhuv1.Jinghzhu
+	err := c.waitForEvent(ctx, name, &result, func(event watch.Event) (bool, error) {
+		if event.Type == watch.Deleted {
+			return false, apierrors.NewNotFound(jinghzhuv1.Resource(c.plural), name)
+		}
+		result = event.Object.(*jinghzhuv1.Jinghzhu)
+
+		return predicate(result)
+	})
+
+	return result, err
+}
+
+// WaitForState waits until the state of the CRD instance is the given one, e.g. types.StateRunning.
+func (c *Client) WaitForState(ctx context.Context, name string, state types.State) (*jinghzhuv1.Jinghzhu, error) {
+	return c.WaitFor(ctx, name, func(instance *jinghzhuv1.Jinghzhu) (bool, error) {
+		return instance.Status.State == state, nil
+	})
+}
+
+// WaitForCondition waits until the CRD instance has the condition of the given type, e.g.
+// jinghzhuv1.ConditionAvailable, with the status, and the condition was set for the latest generation.
+// It is the counterpart of kubectl wait --for=condition=<type>.
+func (c *Client) WaitForCondition(ctx context.Context, name, conditionType string, status metav1.ConditionStatus) (*jinghzhuv1.Jinghzhu, error) {
+	return c.WaitFor(ctx, name, func(instance *jinghzhuv1.Jinghzhu) (bool, error) {
+		condition := jinghzhuv1.FindStatusCondition(instance.Status.Conditions, conditionType)
+
+		return condition != nil && condition.Status == status && condition.ObservedGeneration >= instance.GetGeneration(), nil
+	})
+}
+
+// WaitForDesiredReached waits until the current Pod number of the CRD instance equals the desired one,
+// i.e. condition Available is True. For instances without conditions, it compares Status.Replicas.
+func (c *Client) WaitForDesiredReached(ctx context.Context, name string) (*jinghzhuv1.Jinghzhu, error) {
+	return c.WaitFor(ctx, name, func(instance *jinghzhuv1.Jinghzhu) (bool, error) {
+		condition := jinghzhuv1.FindStatusCondition(instance.Status.Conditions, jinghzhuv1.ConditionAvailable)
+		if condition == nil {
+			return instance.Status.Replicas == instance.Spec.Desired, nil
+		}
+
+		return condition.Status == metav1.ConditionTrue && condition.ObservedGeneration >= instance.GetGeneration(), nil
+	})
+}
+
+// WaitForInstanceProcessed waits until the controller has picked up the CRD instance, which means it
+// has set the conditions, or its state has moved on from types.StatePending.
+func (c *Client) WaitForInstanceProcessed(ctx context.Context, name string) (*jinghzhuv1.Jinghzhu, error) {
+	return c.WaitFor(ctx, name, func(instance *jinghzhuv1.Jinghzhu) (bool, error) {
+		if len(instance.Status.Conditions) > 0 {
+			return true, nil
+		}
+
+		return instance.Status.State != "" && instance.Status.State != types.StatePending, nil
+	})
+}
+
+// WaitForDeletion waits until the CRD instance is gone, i.e. all of its finalizers have run.
+func (c *Client) WaitForDeletion(ctx context.Context, name string) error {
+	var last *jinghzhuv1.Jinghzhu
+	err := c.waitForEvent(ctx, name, &last, func(event watch.Event) (bool, error) {
+		if event.Type == watch.Deleted {
+			return true, nil
+		}
+		last = event.Object.(*jinghzhuv1.Jinghzhu)
+
+		return false, nil
+	})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+
+	return err
+}
+
+// waitForEvent gets the instance, feeds it to condition as an Added event, then watches it from its
+// resourceVersion and feeds every change to condition until condition returns true. last is kept up
+// to date with the latest instance to build *WaitTimeoutError.
+func (c *Client) waitForEvent(ctx context.Context, name string, last **jinghzhuv1.Jinghzhu, condition watchtools.ConditionFunc) error {
+	jinghzhus := c.clientset.JinghzhuV1().Jinghzhus(c.namespace)
+	fieldSelector := fields.OneTermEqualSelector("metadata.name", name).String()
+	watcher := &cache.ListWatch{
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			options.FieldSelector = fieldSelector
+			return jinghzhus.Watch(ctx, options)
+		},
+	}
+
+	for {
+		instance, err := jinghzhus.Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return c.waitError(ctx, name, *last, err)
+		}
+		done, err := condition(watch.Event{Type: watch.Added, Object: instance})
+		if err != nil || done {
+			return err
+		}
+
+		watchCondition := func(event watch.Event) (bool, error) {
+			switch event.Type {
+			case watch.Bookmark:
+				return false, nil
+			case watch.Error:
+				return false, apierrors.FromObject(event.Object)
+			}
+
+			return condition(event)
+		}
+		if instance.GetResourceVersion() == "" {
+			// There is nothing to resume from, e.g. the fake clientset doesn't set resourceVersion.
+			w, err := watcher.Watch(metav1.ListOptions{})
+			if err != nil {
+				return c.waitError(ctx, name, *last, err)
+			}
+			_, err = watchtools.UntilWithoutRetry(ctx, w, watchCondition)
+
+			return c.waitError(ctx, name, *last, err)
+		}
+		_, err = watchtools.Until(ctx, instance.GetResourceVersion(), watcher, watchCondition)
+		if apierrors.IsGone(err) || apierrors.IsResourceExpired(err) {
+			// The resourceVersion is too old to resume from. Start over with the latest instance.
+			continue
+		}
+
+		return c.waitError(ctx, name, *last, err)
+	}
+}
+
+// waitError turns the error caused by a done context into *WaitTimeoutError.
+func (c *Client) waitError(ctx context.Context, name string, last *jinghzhuv1.Jinghzhu, err error) error {
+	if err == nil {
+		return nil
+	}
+	// Once ctx is done, the watch and the API calls fail with various errors. Report them as a timeout,
+	// unless the instance is really gone.
+	if ctx.Err() != nil && !apierrors.IsNotFound(err) {
+		return &WaitTimeoutError{Namespace: c.namespace, Name: name, Last: last, Err: ctx.Err()}
+	}
+
+	return err
+}
@@ -0,0 +1,239 @@
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	jinghzhuv1 "github.com/jinghzhu/KubernetesCRD/pkg/crd/jinghzhu/v1"
+	jinghzhuv1fake "github.com/jinghzhu/KubernetesCRD/pkg/crd/jinghzhu/v1/apis/clientset/versioned/fake"
+	"github.com/jinghzhu/KubernetesCRD/pkg/types"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// waitTimeout is the timeout of the waits which are expected to time out.
+const waitTimeout = 100 * time.Millisecond
+
+// withState returns a copy of instance with the given state, replicas and resourceVersion.
+func withState(instance *jinghzhuv1.Jinghzhu, state types.State, replicas int, resourceVersion string) *jinghzhuv1.Jinghzhu {
+	changed := instance.DeepCopy()
+	changed.Status.State = state
+	changed.Status.Replicas = replicas
+	changed.ResourceVersion = resourceVersion
+
+	return changed
+}
+
+func TestWaitFor(t *testing.T) {
+	pending := newInstance("wait", nil, 2, types.StatePending)
+	pending.ResourceVersion = "1"
+	waitForState := func(ctx context.Context, c *Client) (*jinghzhuv1.Jinghzhu, error) {
+		return c.WaitForState(ctx, pending.Name, types.StateRunning)
+	}
+	waitForDesired := func(ctx context.Context, c *Client) (*jinghzhuv1.Jinghzhu, error) {
+		return c.WaitForDesiredReached(ctx, pending.Name)
+	}
+
+	tests := []struct {
+		name    string
+		initial *jinghzhuv1.Jinghzhu
+		wait    func(ctx context.Context, c *Client) (*jinghzhuv1.Jinghzhu, error)
+		// events are sent on the watches in order. update is written to the fake clientset once the first
+		// watch is up, so only a Get which starts over sees it.
+		events [][]watch.Event
+		update *jinghzhuv1.Jinghzhu
+		// wantReplicas is checked on the returned instance, unless wantErr is set.
+		wantReplicas int
+		wantErr      func(err error) bool
+	}{
+		{
+			name:    "already there",
+			initial: withState(pending, types.StateRunning, 0, "1"),
+			wait:    waitForState,
+		},
+		{
+			name:         "state reached",
+			initial:      pending,
+			wait:         waitForState,
+			events:       [][]watch.Event{{{Type: watch.Modified, Object: withState(pending, types.StateRunning, 1, "2")}}},
+			wantReplicas: 1,
+		},
+		{
+			name:    "desired reached",
+			initial: pending,
+			wait:    waitForDesired,
+			events: [][]watch.Event{{
+				{Type: watch.Modified, Object: withState(pending, types.StateRunning, 1, "2")},
+				{Type: watch.Bookmark, Object: withState(pending, "", 0, "3")},
+				{Type: watch.Modified, Object: withState(pending, types.StateRunning, 2, "4")},
+			}},
+			wantReplicas: 2,
+		},
+		{
+			name:    "expired",
+			initial: pending,
+			wait:    waitForDesired,
+			events: [][]watch.Event{
+				{{Type: watch.Error, Object: &apierrors.NewResourceExpired("too old resource version").ErrStatus}},
+				{{Type: watch.Modified, Object: withState(pending, types.StateRunning, 2, "6")}},
+			},
+			update:       withState(pending, types.StateRunning, 1, "5"),
+			wantReplicas: 2,
+		},
+		{
+			name:    "deleted",
+			initial: pending,
+			wait:    waitForState,
+			events:  [][]watch.Event{{{Type: watch.Deleted, Object: withState(pending, types.StatePending, 0, "2")}}},
+			wantErr: apierrors.IsNotFound,
+		},
+		{
+			name:    "not found",
+			wait:    waitForState,
+			wantErr: apierrors.IsNotFound,
+		},
+		{
+			name:    "timeout",
+			initial: pending,
+			wait:    waitForState,
+			events:  [][]watch.Event{{{Type: watch.Modified, Object: withState(pending, types.StatePending, 1, "2")}}},
+			wantErr: func(err error) bool {
+				timeout, ok := err.(*WaitTimeoutError)
+
+				return ok && timeout.Err == context.DeadlineExceeded && timeout.Last != nil && timeout.Last.Status.Replicas == 1
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var c *Client
+			var clientset *jinghzhuv1fake.Clientset
+			if tt.initial != nil {
+				c, clientset = newFakeClient(tt.initial)
+			} else {
+				c, clientset = newFakeClient()
+			}
+			watches := newFakeWatches(clientset)
+			timeout := wait.ForeverTestTimeout
+			if tt.wantErr != nil {
+				timeout = waitTimeout
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			type result struct {
+				instance *jinghzhuv1.Jinghzhu
+				err      error
+			}
+			done := make(chan result, 1)
+			go func() {
+				instance, err := tt.wait(ctx, c)
+				done <- result{instance, err}
+			}()
+			for i, events := range tt.events {
+				watcher := watches.next(t)
+				if i == 0 && tt.update != nil {
+					if err := clientset.Tracker().Update(jinghzhuv1.SchemeGroupVersion.WithResource(jinghzhuv1.Plural), tt.update, testNamespace); err != nil {
+						t.Fatal(err)
+					}
+				}
+				for _, event := range events {
+					watcher.Action(event.Type, event.Object)
+				}
+			}
+
+			var got result
+			select {
+			case got = <-done:
+			case <-time.After(wait.ForeverTestTimeout):
+				t.Fatal("timed out waiting for the wait to return")
+			}
+			if tt.wantErr != nil {
+				if !tt.wantErr(got.err) {
+					t.Errorf("error = %v (%T)", got.err, got.err)
+				}
+
+				return
+			}
+			if got.err != nil {
+				t.Fatalf("error = %v", got.err)
+			}
+			if got.instance.Status.Replicas != tt.wantReplicas {
+				t.Errorf("got %d replicas, want %d", got.instance.Status.Replicas, tt.wantReplicas)
+			}
+			if n := len(watches.requests()); n != len(tt.events) {
+				t.Errorf("got %d watches, want %d", n, len(tt.events))
+			}
+		})
+	}
+}
+
+func TestWaitForDeletion(t *testing.T) {
+	instance := newInstance("deleting", nil, 1, types.StateRunning)
+	instance.ResourceVersion = "1"
+	tests := []struct {
+		name    string
+		initial *jinghzhuv1.Jinghzhu
+		events  []watch.Event
+		wantErr bool
+	}{
+		{"gone already", nil, nil, false},
+		{"deleted", instance, []watch.Event{
+			{Type: watch.Modified, Object: withState(instance, types.StateRunning, 0, "2")},
+			{Type: watch.Deleted, Object: withState(instance, types.StateRunning, 0, "3")},
+		}, false},
+		{"timeout", instance, []watch.Event{{Type: watch.Modified, Object: withState(instance, types.StateRunning, 0, "2")}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var c *Client
+			var clientset *jinghzhuv1fake.Clientset
+			if tt.initial != nil {
+				c, clientset = newFakeClient(tt.initial)
+			} else {
+				c, clientset = newFakeClient()
+			}
+			watches := newFakeWatches(clientset)
+			timeout := wait.ForeverTestTimeout
+			if tt.wantErr {
+				timeout = waitTimeout
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			done := make(chan error, 1)
+			go func() {
+				done <- c.WaitForDeletion(ctx, instance.Name)
+			}()
+			if tt.initial != nil {
+				watcher := watches.next(t)
+				for _, event := range tt.events {
+					watcher.Action(event.Type, event.Object)
+				}
+			}
+
+			var err error
+			select {
+			case err = <-done:
+			case <-time.After(wait.ForeverTestTimeout):
+				t.Fatal("timed out waiting for WaitForDeletion to return")
+			}
+			if !tt.wantErr {
+				if err != nil {
+					t.Errorf("WaitForDeletion() error = %v", err)
+				}
+
+				return
+			}
+			timeoutErr, ok := err.(*WaitTimeoutError)
+			if !ok {
+				t.Fatalf("WaitForDeletion() error = %v, want *WaitTimeoutError", err)
+			}
+			if timeoutErr.Name != instance.Name || timeoutErr.Last == nil || timeoutErr.Last.ResourceVersion != "2" {
+				t.Errorf("got %+v, want the last observed instance", timeoutErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,264 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	jinghzhuv1 "github.com/jinghzhu/KubernetesCRD/pkg/crd/jinghzhu/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	apimachinerytypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+const (
+	// watchMinBackoff and watchMaxBackoff bound the delay before Watch retries after an error.
+	watchMinBackoff time.Duration = 100 * time.Millisecond
+	watchMaxBackoff time.Duration = 30 * time.Second
+)
+
+// WatchEvent is a change of a CRD instance sent by Watch. Type is watch.Added, watch.Modified or
+// watch.Deleted. For watch.Deleted, Object is the last state of the instance.
+type WatchEvent struct {
+	Type   watch.EventType
+	Object *jinghzhuv1.Jinghzhu
+}
+
+// Watch sends the changes of the CRD instances matching query on the first channel until ctx is done. A
+// nil query matches all instances. The events look as if the predicates of the query were checked by the
+// API server, like the ones of WatchQuery. If resourceVersion is empty, it lists the instances first and
+// sends each of them as watch.Added, otherwise it starts right after that resourceVersion.
+// The watch survives what kills a raw one:
+// * When the server closes it, e.g. on timeout, it resumes from the last seen resourceVersion. It asks
+// for bookmarks, so that resourceVersion stays recent even if nothing matching changes.
+// * When that resourceVersion is too old, i.e. 410 Gone, it lists again in pages, see ListEach, and sends
+// the differences to what it has sent before as watch.Added, watch.Modified and watch.Deleted events. An
+// instance deleted and created again under the same name in between is sent as watch.Deleted and then
+// watch.Added.
+// * Other errors are retried with backoff. A failed relist is retried until it succeeds, rather than
+// watching from a stale resourceVersion.
+// Errors which retrying can't fix, e.g. Forbidden, an invalid selector or *MatchError, end the watch.
+// The first channel is closed when the watch ends, and then the error, if any, is sent on the second
+// one. The error is nil when ctx is done.
+func (c *Client) Watch(ctx context.Context, query *Query, resourceVersion string) (<-chan WatchEvent, <-chan error) {
+	events := make(chan WatchEvent)
+	errCh := make(chan error, 1)
+	if query == nil {
+		query = NewQuery()
+	}
+	go func() {
+		defer close(errCh)
+		err := c.watch(ctx, query, resourceVersion, events)
+		close(events)
+		if err != nil && ctx.Err() == nil {
+			errCh <- err
+		}
+	}()
+
+	return events, errCh
+}
+
+func (c *Client) watch(ctx context.Context, query *Query, resourceVersion string, events chan<- WatchEvent) error {
+	opts, err := query.ListOptions()
+	if err != nil {
+		return err
+	}
+	w := &resumingWatch{
+		client:          c,
+		query:           query,
+		opts:            opts,
+		events:          events,
+		known:           make(map[apimachinerytypes.UID]*jinghzhuv1.Jinghzhu),
+		resourceVersion: resourceVersion,
+	}
+
+	return w.run(ctx)
+}
+
+// resumingWatch is the state of one Watch.
+type resumingWatch struct {
+	client *Client
+	query  *Query
+	// opts holds the selectors of query.
+	opts   metav1.ListOptions
+	events chan<- WatchEvent
+	// known holds the instances sent so far by UID, so a relist can tell what has changed.
+	known map[apimachinerytypes.UID]*jinghzhuv1.Jinghzhu
+	// resourceVersion is the last one seen, which the next watch starts from.
+	resourceVersion string
+}
+
+func (w *resumingWatch) run(ctx context.Context) error {
+	relist := w.resourceVersion == ""
+	backoff := watchMinBackoff
+	for ctx.Err() == nil {
+		var err error
+		if relist {
+			err = w.relist(ctx)
+		} else {
+			err = w.watch(ctx, &backoff)
+		}
+		expired := apierrors.IsGone(err) || apierrors.IsResourceExpired(err)
+		_, isMatchError := err.(*MatchError)
+		switch {
+		case err == nil:
+			if relist {
+				relist = false
+				backoff = watchMinBackoff
+			}
+
+			continue
+		case expired && !relist:
+			relist = true
+
+			continue
+		case ctx.Err() != nil:
+			return nil
+		case isMatchError || apierrors.IsForbidden(err) || apierrors.IsUnauthorized(err) ||
+			apierrors.IsBadRequest(err) || apierrors.IsInvalid(err) || apierrors.IsMethodNotSupported(err):
+			return err
+		}
+
+		// A failed relist is retried as a relist, because the resourceVersion to watch from is stale.
+		fmt.Printf("Fail to watch Jinghzhu in %s, retry in %v: %v\n", w.client.namespace, backoff, err)
+		select {
+		case <-ctx.Done():
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > watchMaxBackoff {
+			backoff = watchMaxBackoff
+		}
+	}
+
+	return nil
+}
+
+// relist lists the matching instances page by page and sends what has changed since they were last
+// sent. The instances are told apart by UID, so one deleted and created again under the same name is
+// sent as watch.Deleted and then watch.Added. If a continue token expires, the error is returned and run
+// starts the relist over.
+func (w *resumingWatch) relist(ctx context.Context) error {
+	opts := w.opts
+	if opts.Limit == 0 {
+		opts.Limit = DefaultPageSize
+	}
+	// All pages come from the snapshot of the first one, so its resourceVersion is the one to resume from.
+	resourceVersion := ""
+	listPager := w.client.newListPager(func(list *jinghzhuv1.JinghzhuList) {
+		if resourceVersion == "" {
+			resourceVersion = list.GetResourceVersion()
+		}
+	})
+
+	// The events are sent once the list is complete, so the deletions can go first.
+	listed := make(map[apimachinerytypes.UID]bool)
+	changes := make([]WatchEvent, 0)
+	err := listPager.EachListItem(ctx, opts, func(obj runtime.Object) error {
+		instance, ok := obj.(*jinghzhuv1.Jinghzhu)
+		if !ok {
+			return fmt.Errorf("unexpected object %T in the list of Jinghzhu", obj)
+		}
+		old, known := w.known[instance.GetUID()]
+		eventType, err := w.query.eventType(watch.Added, instance, known)
+		if err != nil {
+			return err
+		}
+		if eventType == "" || eventType == watch.Deleted {
+			// It doesn't match, so it is sent as deleted below if it is known.
+			return nil
+		}
+		listed[instance.GetUID()] = true
+		if known && old.GetResourceVersion() == instance.GetResourceVersion() {
+			return nil
+		}
+		changes = append(changes, WatchEvent{Type: eventType, Object: instance})
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for uid, old := range w.known {
+		if !listed[uid] {
+			if err = w.send(ctx, watch.Deleted, old); err != nil {
+				return err
+			}
+		}
+	}
+	for _, change := range changes {
+		if err = w.send(ctx, change.Type, change.Object); err != nil {
+			return err
+		}
+	}
+	w.resourceVersion = resourceVersion
+
+	return nil
+}
+
+// watch watches from the last seen resourceVersion until the server closes the watch or fails. It
+// resets backoff once the watch delivers anything.
+func (w *resumingWatch) watch(ctx context.Context, backoff *time.Duration) error {
+	opts := w.opts
+	opts.ResourceVersion = w.resourceVersion
+	opts.AllowWatchBookmarks = true
+	watcher, err := w.client.clientset.JinghzhuV1().Jinghzhus(w.client.namespace).Watch(ctx, opts)
+	if err != nil {
+		return err
+	}
+	defer watcher.Stop()
+
+	for {
+		var event watch.Event
+		var ok bool
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case event, ok = <-watcher.ResultChan():
+		}
+		if !ok {
+			return nil
+		}
+		if event.Type == watch.Error {
+			return apierrors.FromObject(event.Object)
+		}
+		instance, isJinghzhu := event.Object.(*jinghzhuv1.Jinghzhu)
+		if !isJinghzhu {
+			return fmt.Errorf("unexpected object %T in the watch of Jinghzhu", event.Object)
+		}
+		*backoff = watchMinBackoff
+		if instance.GetResourceVersion() != "" {
+			w.resourceVersion = instance.GetResourceVersion()
+		}
+		if event.Type == watch.Bookmark {
+			continue
+		}
+		_, known := w.known[instance.GetUID()]
+		eventType, err := w.query.eventType(event.Type, instance, known)
+		if err != nil {
+			return err
+		}
+		if eventType == "" {
+			continue
+		}
+		if err = w.send(ctx, eventType, instance); err != nil {
+			return err
+		}
+	}
+}
+
+// send delivers an event and records the instance in known.
+func (w *resumingWatch) send(ctx context.Context, eventType watch.EventType, instance *jinghzhuv1.Jinghzhu) error {
+	if eventType == watch.Deleted {
+		delete(w.known, instance.GetUID())
+	} else {
+		w.known[instance.GetUID()] = instance
+	}
+	select {
+	case w.events <- WatchEvent{Type: eventType, Object: instance}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
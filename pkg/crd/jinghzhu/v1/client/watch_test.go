@@ -0,0 +1,200 @@
+package client
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	jinghzhuv1 "github.com/jinghzhu/KubernetesCRD/pkg/crd/jinghzhu/v1"
+	"github.com/jinghzhu/KubernetesCRD/pkg/types"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	apimachinerytypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// fakeWatches hands out the fake watchers to the watch requests of a fake clientset in order, and
+// records the resourceVersion each request starts from.
+type fakeWatches struct {
+	mu               sync.Mutex
+	watchers         chan *watch.FakeWatcher
+	resourceVersions []string
+}
+
+func newFakeWatches(reactor interface {
+	PrependWatchReactor(resource string, reaction k8stesting.WatchReactionFunc)
+}) *fakeWatches {
+	w := &fakeWatches{watchers: make(chan *watch.FakeWatcher, 10)}
+	reactor.PrependWatchReactor(jinghzhuv1.Plural, func(action k8stesting.Action) (bool, watch.Interface, error) {
+		watcher := watch.NewFake()
+		w.mu.Lock()
+		w.resourceVersions = append(w.resourceVersions, action.(k8stesting.WatchActionImpl).GetWatchRestrictions().ResourceVersion)
+		w.mu.Unlock()
+		w.watchers <- watcher
+
+		return true, watcher, nil
+	})
+
+	return w
+}
+
+// next returns the watcher of the next watch request.
+func (w *fakeWatches) next(t *testing.T) *watch.FakeWatcher {
+	t.Helper()
+	select {
+	case watcher := <-w.watchers:
+		return watcher
+	case <-time.After(wait.ForeverTestTimeout):
+		t.Fatal("timed out waiting for a watch request")
+	}
+
+	return nil
+}
+
+// requests returns the resourceVersions of the watch requests so far.
+func (w *fakeWatches) requests() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return append([]string(nil), w.resourceVersions...)
+}
+
+// receive returns the next n events of Watch.
+func receive(t *testing.T, events <-chan WatchEvent, n int) []WatchEvent {
+	t.Helper()
+	received := make([]WatchEvent, 0, n)
+	for len(received) < n {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				t.Fatalf("events closed after %v", received)
+			}
+			received = append(received, event)
+		case <-time.After(wait.ForeverTestTimeout):
+			t.Fatalf("timed out after %d of %d events", len(received), n)
+		}
+	}
+
+	return received
+}
+
+// eventIndex returns the position of the event with the given type and UID, or -1.
+func eventIndex(events []WatchEvent, eventType watch.EventType, uid apimachinerytypes.UID) int {
+	for i, event := range events {
+		if event.Type == eventType && event.Object.GetUID() == uid {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func TestWatchResumesAndRelists(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	a := newInstance("a", nil, 1, types.StateRunning)
+	a.ResourceVersion = "1"
+	b := newInstance("b", nil, 1, types.StateRunning)
+	b.ResourceVersion = "2"
+	c, clientset := newFakeClient(a, b)
+	watches := newFakeWatches(clientset)
+	failList := false
+	clientset.PrependReactor("list", jinghzhuv1.Plural, func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if failList {
+			failList = false
+
+			return true, nil, apierrors.NewInternalError(errFake)
+		}
+
+		return false, nil, nil
+	})
+
+	events, errCh := c.Watch(ctx, nil, "")
+
+	// Without a resourceVersion, it lists first.
+	received := receive(t, events, 2)
+	if eventIndex(received, watch.Added, a.UID) < 0 || eventIndex(received, watch.Added, b.UID) < 0 {
+		t.Fatalf("got %v, want a and b added", received)
+	}
+
+	// A bookmark moves the resourceVersion on without an event, and a closed watch resumes from there.
+	first := watches.next(t)
+	first.Action(watch.Bookmark, &jinghzhuv1.Jinghzhu{ObjectMeta: metav1.ObjectMeta{ResourceVersion: "10"}})
+	first.Stop()
+	second := watches.next(t)
+	if got := watches.requests(); len(got) != 2 || got[1] != "10" {
+		t.Fatalf("watches started from %q, want the second one from the bookmark 10", got)
+	}
+	modified := b.DeepCopy()
+	modified.ResourceVersion = "11"
+	modified.Spec.Desired = 2
+	second.Modify(modified)
+	if received = receive(t, events, 1); received[0].Type != watch.Modified || received[0].Object.GetUID() != b.UID {
+		t.Fatalf("got %v, want b modified", received)
+	}
+
+	// While the watch is down, a is deleted and created again, and c is created.
+	gvr := jinghzhuv1.SchemeGroupVersion.WithResource(jinghzhuv1.Plural)
+	if err := clientset.Tracker().Delete(gvr, testNamespace, a.Name); err != nil {
+		t.Fatal(err)
+	}
+	recreated := newInstance("a", nil, 1, types.StateRunning)
+	recreated.UID = "a-uid-2"
+	recreated.ResourceVersion = "12"
+	newInstanceC := newInstance("c", nil, 1, types.StateRunning)
+	newInstanceC.ResourceVersion = "13"
+	for _, instance := range []*jinghzhuv1.Jinghzhu{recreated, newInstanceC} {
+		if err := clientset.Tracker().Create(gvr, instance, testNamespace); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := clientset.Tracker().Update(gvr, modified, testNamespace); err != nil {
+		t.Fatal(err)
+	}
+	// The resourceVersion is too old, and the first relist fails.
+	failList = true
+	second.Error(&apierrors.NewResourceExpired("too old resource version").ErrStatus)
+
+	received = receive(t, events, 3)
+	deleted, added := eventIndex(received, watch.Deleted, a.UID), eventIndex(received, watch.Added, recreated.UID)
+	if deleted < 0 || added < 0 || deleted > added {
+		t.Errorf("got %v, want the old a deleted before the new a is added", received)
+	}
+	if eventIndex(received, watch.Added, newInstanceC.UID) < 0 {
+		t.Errorf("got %v, want c added", received)
+	}
+	// The relist is retried rather than watching from the expired resourceVersion.
+	watches.next(t)
+	if got := watches.requests(); len(got) != 3 {
+		t.Errorf("got %d watches, want the third one only after the relist", len(got))
+	}
+
+	cancel()
+	for range events {
+	}
+	if err := <-errCh; err != nil {
+		t.Errorf("Watch() error = %v, want nil once ctx is done", err)
+	}
+}
+
+func TestWatchFatalError(t *testing.T) {
+	c, clientset := newFakeClient()
+	watches := newFakeWatches(clientset)
+
+	events, errCh := c.Watch(context.Background(), nil, "5")
+	watcher := watches.next(t)
+	if got := watches.requests(); got[0] != "5" {
+		t.Errorf("watch started from %q, want 5", got[0])
+	}
+	watcher.Error(&apierrors.NewForbidden(jinghzhuv1.SchemeGroupVersion.WithResource(jinghzhuv1.Plural).GroupResource(), "", errFake).ErrStatus)
+
+	for range events {
+	}
+	if err := <-errCh; !apierrors.IsForbidden(err) {
+		t.Errorf("Watch() error = %v, want Forbidden", err)
+	}
+}
@@ -0,0 +1,87 @@
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// ConditionAvailable is True when all desired Pods are running.
+	ConditionAvailable string = "Available"
+	// ConditionProgressing is True while the controller is creating, starting or deleting Pods to reach
+	// the desired number.
+	ConditionProgressing string = "Progressing"
+	// ConditionDegraded is True when the controller has failed to reconcile and given up retrying.
+	ConditionDegraded string = "Degraded"
+)
+
+// SetStatusCondition sets the condition in conditions. If the condition is new or its status changes,
+// LastTransitionTime is set to the one of the given condition, or now if that is zero. Otherwise it is
+// kept. Reason, Message and ObservedGeneration are always updated.
+func SetStatusCondition(conditions *[]JinghzhuCondition, newCondition JinghzhuCondition) {
+	if conditions == nil {
+		return
+	}
+	existing := FindStatusCondition(*conditions, newCondition.Type)
+	if existing == nil {
+		if newCondition.LastTransitionTime.IsZero() {
+			newCondition.LastTransitionTime = metav1.Now()
+		}
+		*conditions = append(*conditions, newCondition)
+
+		return
+	}
+
+	if existing.Status != newCondition.Status {
+		existing.Status = newCondition.Status
+		if newCondition.LastTransitionTime.IsZero() {
+			existing.LastTransitionTime = metav1.Now()
+		} else {
+			existing.LastTransitionTime = newCondition.LastTransitionTime
+		}
+	}
+	existing.Reason = newCondition.Reason
+	existing.Message = newCondition.Message
+	existing.ObservedGeneration = newCondition.ObservedGeneration
+}
+
+// RemoveStatusCondition removes the condition of the given type from conditions.
+func RemoveStatusCondition(conditions *[]JinghzhuCondition, conditionType string) {
+	if conditions == nil || len(*conditions) == 0 {
+		return
+	}
+	newConditions := make([]JinghzhuCondition, 0, len(*conditions))
+	for _, condition := range *conditions {
+		if condition.Type != conditionType {
+			newConditions = append(newConditions, condition)
+		}
+	}
+	*conditions = newConditions
+}
+
+// FindStatusCondition returns the condition of the given type, or nil if it isn't set.
+func FindStatusCondition(conditions []JinghzhuCondition, conditionType string) *JinghzhuCondition {
+	for i := range conditions {
+		if conditions[i].Type == conditionType {
+			return &conditions[i]
+		}
+	}
+
+	return nil
+}
+
+// IsStatusConditionTrue tells whether the condition of the given type is set and True.
+func IsStatusConditionTrue(conditions []JinghzhuCondition, conditionType string) bool {
+	return IsStatusConditionPresentAndEqual(conditions, conditionType, metav1.ConditionTrue)
+}
+
+// IsStatusConditionFalse tells whether the condition of the given type is set and False.
+func IsStatusConditionFalse(conditions []JinghzhuCondition, conditionType string) bool {
+	return IsStatusConditionPresentAndEqual(conditions, conditionType, metav1.ConditionFalse)
+}
+
+// IsStatusConditionPresentAndEqual tells whether the condition of the given type is set with the status.
+func IsStatusConditionPresentAndEqual(conditions []JinghzhuCondition, conditionType string, status metav1.ConditionStatus) bool {
+	condition := FindStatusCondition(conditions, conditionType)
+
+	return condition != nil && condition.Status == status
+}
@@ -0,0 +1,67 @@
+package v1
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSetStatusCondition(t *testing.T) {
+	before := metav1.NewTime(time.Now().Add(-time.Hour).Truncate(time.Second))
+	given := metav1.NewTime(time.Now().Add(-time.Minute).Truncate(time.Second))
+	existing := JinghzhuCondition{
+		Type:               ConditionAvailable,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: 1,
+		LastTransitionTime: before,
+		Reason:             "PodsNotRunning",
+		Message:            "0/1 Pods running",
+	}
+
+	tests := []struct {
+		name       string
+		conditions []JinghzhuCondition
+		condition  JinghzhuCondition
+		// wantTime is the expected lastTransitionTime, or nil for about now.
+		wantTime *metav1.Time
+		wantLen  int
+	}{
+		{"new", nil, JinghzhuCondition{Type: ConditionAvailable, Status: metav1.ConditionFalse, Reason: "PodsNotRunning"}, nil, 1},
+		{"new with time", nil, JinghzhuCondition{Type: ConditionAvailable, Status: metav1.ConditionFalse, LastTransitionTime: given}, &given, 1},
+		{"same status", []JinghzhuCondition{existing}, JinghzhuCondition{Type: ConditionAvailable, Status: metav1.ConditionFalse, Reason: "Other"}, &before, 1},
+		{"same status with time", []JinghzhuCondition{existing}, JinghzhuCondition{Type: ConditionAvailable, Status: metav1.ConditionFalse, LastTransitionTime: given}, &before, 1},
+		{"status flips", []JinghzhuCondition{existing}, JinghzhuCondition{Type: ConditionAvailable, Status: metav1.ConditionTrue, Reason: "DesiredReached"}, nil, 1},
+		{"status flips with time", []JinghzhuCondition{existing}, JinghzhuCondition{Type: ConditionAvailable, Status: metav1.ConditionTrue, LastTransitionTime: given}, &given, 1},
+		{"other type", []JinghzhuCondition{existing}, JinghzhuCondition{Type: ConditionProgressing, Status: metav1.ConditionFalse}, nil, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conditions := append([]JinghzhuCondition(nil), tt.conditions...)
+			tt.condition.ObservedGeneration = 2
+			tt.condition.Message = "changed"
+			start := time.Now().Add(-time.Second)
+
+			SetStatusCondition(&conditions, tt.condition)
+			got := FindStatusCondition(conditions, tt.condition.Type)
+			if got == nil {
+				t.Fatalf("condition %s isn't set in %+v", tt.condition.Type, conditions)
+			}
+			if got.Status != tt.condition.Status || got.Reason != tt.condition.Reason || got.Message != "changed" || got.ObservedGeneration != 2 {
+				t.Errorf("got %+v, want the status, reason, message and generation of %+v", got, tt.condition)
+			}
+			if tt.wantTime != nil && !got.LastTransitionTime.Equal(tt.wantTime) {
+				t.Errorf("lastTransitionTime = %v, want %v", got.LastTransitionTime, tt.wantTime)
+			}
+			if tt.wantTime == nil && got.LastTransitionTime.Time.Before(start) {
+				t.Errorf("lastTransitionTime = %v, want now", got.LastTransitionTime)
+			}
+			if len(conditions) != tt.wantLen {
+				t.Errorf("got %d conditions, want %d", len(conditions), tt.wantLen)
+			}
+		})
+	}
+
+	// A nil slice pointer is ignored.
+	SetStatusCondition(nil, existing)
+}
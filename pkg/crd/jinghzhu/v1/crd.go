@@ -1,65 +1,184 @@
 package v1
 
 import (
+	"context"
 	"fmt"
 	"reflect"
+	"strings"
 	"time"
 
+	"github.com/jinghzhu/KubernetesCRD/pkg/config"
 	crdjinghzhu "github.com/jinghzhu/KubernetesCRD/pkg/crd/jinghzhu"
-	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	jinghzhuv2 "github.com/jinghzhu/KubernetesCRD/pkg/crd/jinghzhu/v2"
+	"github.com/jinghzhu/KubernetesCRD/pkg/crd/schema"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	apiextensionsclientset "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
-	"github.com/jinghzhu/KubernetesCRD/pkg/types"
 	"k8s.io/apimachinery/pkg/util/errors"
-	"k8s.io/apimachinery/pkg/util/wait"
 )
 
-// CreateCustomResourceDefinition creates the CRD and add it into Kubernetes. If there is error,
-// it will do some clean up.
-func CreateCustomResourceDefinition(clientSet apiextensionsclientset.Interface) (*apiextensionsv1beta1.CustomResourceDefinition, error) {
-	crd := &apiextensionsv1beta1.CustomResourceDefinition{
+const (
+	// SpecReplicasPath is the JSON path of the desired replicas used by the scale subresource.
+	SpecReplicasPath string = ".spec.desired"
+	// StatusReplicasPath is the JSON path of the observed replicas used by the scale subresource.
+	StatusReplicasPath string = ".status.replicas"
+	// LabelSelectorPath is the JSON path of the serialized Pod label selector used by the scale subresource.
+	LabelSelectorPath string = ".status.selector"
+	// V2StatusReplicasPath is the JSON path of the observed replicas used by the scale subresource of v2.
+	V2StatusReplicasPath string = ".status.readyReplicas"
+
+	// conversionWebhookPath must match conversion.WebhookPath, which can't be imported here.
+	conversionWebhookPath string = "/convert"
+	conversionWebhookPort int32  = 443
+
+	// crdCleanupTimeout bounds the deletion of a CRD which failed to be established.
+	crdCleanupTimeout time.Duration = 30 * time.Second
+)
+
+// NewCustomResourceDefinition returns the apiextensions.k8s.io/v1 definition of CRD Jinghzhu. If the
+// conversion webhook is configured, see config.GetConversionWebhookService, it also serves v2 and stores
+// instances in v2. Otherwise only v1 is served.
+func NewCustomResourceDefinition() *apiextensionsv1.CustomResourceDefinition {
+	crd := &apiextensionsv1.CustomResourceDefinition{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: CRDName,
 		},
-		Spec: apiextensionsv1beta1.CustomResourceDefinitionSpec{
-			Group:   crdjinghzhu.GroupName,
-			Version: SchemeGroupVersion.Version,
-			Scope:   apiextensionsv1beta1.NamespaceScoped,
-			Names: apiextensionsv1beta1.CustomResourceDefinitionNames{
+		Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+			Group: crdjinghzhu.GroupName,
+			Scope: apiextensionsv1.NamespaceScoped,
+			Names: apiextensionsv1.CustomResourceDefinitionNames{
 				Plural:     Plural,
 				Singular:   Singular,
 				Kind:       reflect.TypeOf(Jinghzhu{}).Name(),
+				ListKind:   reflect.TypeOf(JinghzhuList{}).Name(),
 				ShortNames: []string{ShortName},
 			},
-			Validation: &apiextensionsv1beta1.CustomResourceValidation{
-				OpenAPIV3Schema: &apiextensionsv1beta1.JSONSchemaProps{
-					Type: "object",
-					Properties: map[string]apiextensionsv1beta1.JSONSchemaProps{
-						"spec": {
-							Type: "object",
-							Properties: map[string]apiextensionsv1beta1.JSONSchemaProps{
-								"desired": {Type: "integer", Format: "int"},
-								"current": {Type: "integer", Format: "int"},
-								"podList": {
-									Type: "array",
-									Items: &apiextensionsv1beta1.JSONSchemaPropsOrArray{
-										Schema: &apiextensionsv1beta1.JSONSchemaProps{Type: "string"},
-									},
-								},
-							},
-							Required: []string{"desired"},
-						},
-					},
+			Versions: []apiextensionsv1.CustomResourceDefinitionVersion{
+				newV1Version(),
+			},
+			Conversion: &apiextensionsv1.CustomResourceConversion{
+				Strategy: apiextensionsv1.NoneConverter,
+			},
+		},
+	}
+
+	cfg := config.GetConfig()
+	if cfg.GetConversionWebhookService() == "" {
+		return crd
+	}
+	crd.Spec.Versions[0].Storage = false
+	crd.Spec.Versions = append(crd.Spec.Versions, newV2Version())
+	crd.Spec.Conversion = newWebhookConversion(cfg.GetConversionWebhookService(), cfg.GetConversionWebhookCABundle())
+
+	return crd
+}
+
+// newV1Version returns version v1 of CRD Jinghzhu.
+func newV1Version() apiextensionsv1.CustomResourceDefinitionVersion {
+	labelSelectorPath := LabelSelectorPath
+
+	return apiextensionsv1.CustomResourceDefinitionVersion{
+		Name:    SchemeGroupVersion.Version,
+		Served:  true,
+		Storage: true,
+		Schema: &apiextensionsv1.CustomResourceValidation{
+			OpenAPIV3Schema: schema.Generate(Jinghzhu{}),
+		},
+		Subresources: &apiextensionsv1.CustomResourceSubresources{
+			Status: &apiextensionsv1.CustomResourceSubresourceStatus{},
+			Scale: &apiextensionsv1.CustomResourceSubresourceScale{
+				SpecReplicasPath:   SpecReplicasPath,
+				StatusReplicasPath: StatusReplicasPath,
+				LabelSelectorPath:  &labelSelectorPath,
+			},
+		},
+		AdditionalPrinterColumns: []apiextensionsv1.CustomResourceColumnDefinition{
+			{Name: "Desired", Type: "integer", Description: "The desired number of Pods.", JSONPath: ".spec.desired"},
+			{Name: "Current", Type: "integer", Description: "The number of Pods currently running.", JSONPath: ".status.replicas"},
+			{Name: "State", Type: "string", Description: "The lifecycle state of Jinghzhu.", JSONPath: ".status.state"},
+			{Name: "Message", Type: "string", Description: "The message of the lifecycle state.", JSONPath: ".status.message", Priority: 1},
+			{Name: "Age", Type: "date", JSONPath: ".metadata.creationTimestamp"},
+		},
+	}
+}
+
+// newV2Version returns version v2 of CRD Jinghzhu, which is the storage version.
+func newV2Version() apiextensionsv1.CustomResourceDefinitionVersion {
+	labelSelectorPath := LabelSelectorPath
+
+	return apiextensionsv1.CustomResourceDefinitionVersion{
+		Name:    jinghzhuv2.SchemeGroupVersion.Version,
+		Served:  true,
+		Storage: true,
+		Schema: &apiextensionsv1.CustomResourceValidation{
+			OpenAPIV3Schema: schema.Generate(jinghzhuv2.Jinghzhu{}),
+		},
+		Subresources: &apiextensionsv1.CustomResourceSubresources{
+			Status: &apiextensionsv1.CustomResourceSubresourceStatus{},
+			Scale: &apiextensionsv1.CustomResourceSubresourceScale{
+				SpecReplicasPath:   SpecReplicasPath,
+				StatusReplicasPath: V2StatusReplicasPath,
+				LabelSelectorPath:  &labelSelectorPath,
+			},
+		},
+		AdditionalPrinterColumns: []apiextensionsv1.CustomResourceColumnDefinition{
+			{Name: "Desired", Type: "integer", Description: "The desired number of Pods.", JSONPath: ".spec.desired"},
+			{Name: "Ready", Type: "integer", Description: "The number of Pods currently running.", JSONPath: ".status.readyReplicas"},
+			{Name: "State", Type: "string", Description: "The lifecycle state of Jinghzhu.", JSONPath: ".status.state"},
+			{Name: "Message", Type: "string", Description: "The message of the lifecycle state.", JSONPath: ".status.message", Priority: 1},
+			{Name: "Age", Type: "date", JSONPath: ".metadata.creationTimestamp"},
+		},
+	}
+}
+
+// newWebhookConversion returns the conversion of CRD Jinghzhu through the webhook served by the given
+// Service, in the format of namespace/name or just name in the CRD namespace.
+func newWebhookConversion(service, caBundle string) *apiextensionsv1.CustomResourceConversion {
+	namespace, name := config.GetConfig().GetCRDNamespace(), service
+	if parts := strings.SplitN(service, "/", 2); len(parts) == 2 {
+		namespace, name = parts[0], parts[1]
+	}
+	path := conversionWebhookPath
+	port := conversionWebhookPort
+
+	return &apiextensionsv1.CustomResourceConversion{
+		Strategy: apiextensionsv1.WebhookConverter,
+		Webhook: &apiextensionsv1.WebhookConversion{
+			ClientConfig: &apiextensionsv1.WebhookClientConfig{
+				Service: &apiextensionsv1.ServiceReference{
+					Namespace: namespace,
+					Name:      name,
+					Path:      &path,
+					Port:      &port,
 				},
+				CABundle: []byte(caBundle),
 			},
+			ConversionReviewVersions: []string{"v1", "v1beta1"},
 		},
 	}
-	ctx := types.GetCtx()
-	_, err := clientSet.ApiextensionsV1beta1().CustomResourceDefinitions().Create(ctx, crd, metav1.CreateOptions{})
-	if err == nil {
-		fmt.Println("CRD Jinghzhu is created")
+}
+
+// CreateCustomResourceDefinition creates the CRD and add it into Kubernetes. It detects whether the cluster
+// serves apiextensions.k8s.io/v1 or only v1beta1 and registers the CRD through that API. The returned
+// object is always apiextensions.k8s.io/v1. If the CRD created by this call fails to be established, it
+// is deleted again. An existing CRD is left as it is, even if the wait fails, because deleting it would
+// delete all its instances. Use EnsureCustomResourceDefinition to upgrade it.
+// It watches the CRD until it is established or ctx is done. If ctx has no deadline, it waits for a
+// minute at most. If it fails to be established, the error is *EstablishError.
+func CreateCustomResourceDefinition(ctx context.Context, clientSet apiextensionsclientset.Interface) (*apiextensionsv1.CustomResourceDefinition, error) {
+	crdClient, err := newCRDClient(clientSet)
+	if err != nil {
+		fmt.Printf("Fail to detect apiextensions API version: %+v\n", err)
+
+		return nil, err
+	}
+	crd := NewCustomResourceDefinition()
+	_, err = crdClient.create(ctx, crd)
+	created := err == nil
+	if created {
+		fmt.Printf("CRD Jinghzhu is created via apiextensions.k8s.io/%s\n", crdClient.version)
 	} else if apierrors.IsAlreadyExists(err) {
 		fmt.Println("CRD Jinghzhu already exists")
 	} else {
@@ -69,41 +188,25 @@ func CreateCustomResourceDefinition(clientSet apiextensionsclientset.Interface)
 	}
 
 	// Wait for CRD creation.
-	err = wait.Poll(5*time.Second, 60*time.Second, func() (bool, error) {
-		crd, err = clientSet.ApiextensionsV1beta1().CustomResourceDefinitions().Get(ctx, CRDName, metav1.GetOptions{})
-		if err != nil {
-			fmt.Printf("Fail to wait for CRD Jinghzhu creation: %+v\n", err)
-
-			return false, err
-		}
-		for _, cond := range crd.Status.Conditions {
-			switch cond.Type {
-			case apiextensionsv1beta1.Established:
-				if cond.Status == apiextensionsv1beta1.ConditionTrue {
-					return true, err
-				}
-			case apiextensionsv1beta1.NamesAccepted:
-				if cond.Status == apiextensionsv1beta1.ConditionFalse {
-					fmt.Printf("Name conflict while wait for CRD Jinghzhu creation: %s, %+v\n", cond.Reason, err)
-				}
-			}
-		}
-
-		return false, err
-	})
-
-	// If there is an error, delete the object to keep it clean.
-	if err != nil {
-		fmt.Println("Try to cleanup")
-		deleteErr := clientSet.ApiextensionsV1beta1().CustomResourceDefinitions().Delete(ctx, CRDName, metav1.DeleteOptions{})
-		if deleteErr != nil {
-			fmt.Printf("Fail to delete CRD Jinghzhu: %+v\n", deleteErr)
+	crd, err = waitForEstablished(ctx, crdClient)
+	if err == nil {
+		return crd, nil
+	}
+	if !created {
+		return nil, err
+	}
 
-			return nil, errors.NewAggregate([]error{err, deleteErr})
-		}
+	// Delete the CRD created above to keep it clean.
+	fmt.Println("Try to cleanup")
+	// ctx may be already done, so don't let it cancel the cleanup, but don't let it hang either.
+	cleanupCtx, cancel := context.WithTimeout(context.Background(), crdCleanupTimeout)
+	defer cancel()
+	deleteErr := crdClient.delete(cleanupCtx, CRDName, metav1.DeleteOptions{})
+	if deleteErr != nil {
+		fmt.Printf("Fail to delete CRD Jinghzhu: %+v\n", deleteErr)
 
-		return nil, err
+		return nil, errors.NewAggregate([]error{err, deleteErr})
 	}
 
-	return crd, nil
+	return nil, err
 }
@@ -0,0 +1,135 @@
+package v1
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	apiextensionsclientset "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	apimachinerytypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+)
+
+// DeletionPolicy decides what DeleteCustomResourceDefinition does with the remaining Jinghzhu instances.
+type DeletionPolicy string
+
+const (
+	// DeletionPolicyRefuse refuses to delete the CRD while any Jinghzhu instance exists.
+	DeletionPolicyRefuse DeletionPolicy = "refuse"
+	// DeletionPolicyCascade deletes all Jinghzhu instances first and waits for their finalizers to
+	// finish before deleting the CRD.
+	DeletionPolicyCascade DeletionPolicy = "cascade"
+	// DeletionPolicyForce deletes the CRD right away and strips the finalizers of the instances which
+	// block its removal.
+	DeletionPolicyForce DeletionPolicy = "force"
+)
+
+const (
+	deletionPollInterval time.Duration = time.Second
+)
+
+// DeleteCustomResourceDefinition removes CRD Jinghzhu from Kubernetes according to the policy. It only
+// returns after the CRD has disappeared from the cluster, or when ctx is done.
+func DeleteCustomResourceDefinition(ctx context.Context, clientSet apiextensionsclientset.Interface, dynamicClient dynamic.Interface, policy DeletionPolicy) error {
+	crdClient, err := newCRDClient(clientSet)
+	if err != nil {
+		return err
+	}
+	if _, err = crdClient.get(ctx, CRDName); apierrors.IsNotFound(err) {
+		fmt.Println("CRD Jinghzhu doesn't exist")
+
+		return nil
+	} else if err != nil {
+		return err
+	}
+	instances := dynamicClient.Resource(SchemeGroupVersion.WithResource(Plural))
+
+	switch policy {
+	case DeletionPolicyRefuse:
+		list, err := instances.List(ctx, metav1.ListOptions{Limit: 1})
+		if err != nil {
+			return err
+		}
+		if len(list.Items) > 0 {
+			return fmt.Errorf("refuse to delete CRD %s because instances still exist, e.g. %s/%s",
+				CRDName, list.Items[0].GetNamespace(), list.Items[0].GetName())
+		}
+	case DeletionPolicyCascade:
+		list, err := instances.List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return err
+		}
+		for _, item := range list.Items {
+			err = instances.Namespace(item.GetNamespace()).Delete(ctx, item.GetName(), metav1.DeleteOptions{})
+			if err != nil && !apierrors.IsNotFound(err) {
+				return err
+			}
+		}
+		// Wait for the finalizers of all instances.
+		err = wait.PollImmediateUntil(deletionPollInterval, func() (bool, error) {
+			list, err := instances.List(ctx, metav1.ListOptions{Limit: 1})
+			if err != nil {
+				return false, err
+			}
+
+			return len(list.Items) == 0, nil
+		}, ctx.Done())
+		if err != nil {
+			return fmt.Errorf("fail to wait for instances of CRD %s to be deleted: %v", CRDName, err)
+		}
+	case DeletionPolicyForce:
+	default:
+		return fmt.Errorf("unknown deletion policy %q", policy)
+	}
+
+	err = crdClient.delete(ctx, CRDName, metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	// Wait until the CRD disappears. The API server deletes the remaining instances before removing it.
+	err = wait.PollImmediateUntil(deletionPollInterval, func() (bool, error) {
+		_, err := crdClient.get(ctx, CRDName)
+		if apierrors.IsNotFound(err) {
+			return true, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		if policy == DeletionPolicyForce {
+			return false, removeInstanceFinalizers(ctx, instances)
+		}
+
+		return false, nil
+	}, ctx.Done())
+	if err != nil {
+		return fmt.Errorf("fail to wait for CRD %s to be deleted: %v", CRDName, err)
+	}
+	fmt.Println("CRD Jinghzhu is deleted")
+
+	return nil
+}
+
+// removeInstanceFinalizers clears the finalizers of all Jinghzhu instances so the API server can
+// remove them.
+func removeInstanceFinalizers(ctx context.Context, instances dynamic.NamespaceableResourceInterface) error {
+	list, err := instances.List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	patch := []byte(`{"metadata":{"finalizers":null}}`)
+	for _, item := range list.Items {
+		if len(item.GetFinalizers()) == 0 {
+			continue
+		}
+		_, err = instances.Namespace(item.GetNamespace()).Patch(ctx, item.GetName(), apimachinerytypes.MergePatchType, patch, metav1.PatchOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,152 @@
+package v1
+
+import (
+	"context"
+	"testing"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apiextensionsfake "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/fake"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// newFakeClientSet returns a fake apiextensions clientset with the objects, whose discovery serves the
+// given versions of apiextensions.k8s.io.
+func newFakeClientSet(versions []string, objects ...runtime.Object) *apiextensionsfake.Clientset {
+	clientSet := apiextensionsfake.NewSimpleClientset(objects...)
+	discovery := clientSet.Discovery().(*fakediscovery.FakeDiscovery)
+	for _, version := range versions {
+		discovery.Resources = append(discovery.Resources, &metav1.APIResourceList{
+			GroupVersion: apiextensionsv1.SchemeGroupVersion.Group + "/" + version,
+			APIResources: []metav1.APIResource{{Name: "customresourcedefinitions", Kind: "CustomResourceDefinition"}},
+		})
+	}
+
+	return clientSet
+}
+
+// newUnstructuredInstance returns a Jinghzhu instance as the dynamic client sees it.
+func newUnstructuredInstance(name string, finalizers ...string) *unstructured.Unstructured {
+	instance := &unstructured.Unstructured{}
+	instance.SetAPIVersion(SchemeGroupVersion.String())
+	instance.SetKind(Kind)
+	instance.SetNamespace("crd")
+	instance.SetName(name)
+	instance.SetFinalizers(finalizers)
+
+	return instance
+}
+
+// terminatingCRD makes the fake clientset keep CRD Jinghzhu after its deletion until no instance has
+// finalizers left, like the API server which removes the instances before the CRD.
+func terminatingCRD(t *testing.T, clientSet *apiextensionsfake.Clientset, dynamicClient *dynamicfake.FakeDynamicClient) {
+	deleted := false
+	clientSet.PrependReactor("delete", "customresourcedefinitions", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		deleted = true
+
+		return true, nil, nil
+	})
+	clientSet.PrependReactor("get", "customresourcedefinitions", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if !deleted {
+			return false, nil, nil
+		}
+		list, err := dynamicClient.Resource(SchemeGroupVersion.WithResource(Plural)).List(context.Background(), metav1.ListOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, item := range list.Items {
+			if len(item.GetFinalizers()) > 0 {
+				return false, nil, nil
+			}
+		}
+
+		return true, nil, apierrors.NewNotFound(apiextensionsv1.Resource("customresourcedefinitions"), CRDName)
+	})
+}
+
+func TestDeleteCustomResourceDefinition(t *testing.T) {
+	tests := []struct {
+		name      string
+		policy    DeletionPolicy
+		instances []runtime.Object
+		wantErr   bool
+		// wantInstances are the instances left. wantDeletes and wantPatches count the deletions and the
+		// patches of instances.
+		wantInstances int
+		wantDeletes   int
+		wantPatches   int
+	}{
+		{"refuse with instances", DeletionPolicyRefuse, []runtime.Object{newUnstructuredInstance("a")}, true, 1, 0, 0},
+		{"refuse without instances", DeletionPolicyRefuse, nil, false, 0, 0, 0},
+		{"cascade", DeletionPolicyCascade, []runtime.Object{newUnstructuredInstance("a"), newUnstructuredInstance("b")}, false, 0, 2, 0},
+		{"force", DeletionPolicyForce, []runtime.Object{newUnstructuredInstance("a", FinalizerPodCleanup), newUnstructuredInstance("b")}, false, 2, 0, 1},
+		{"unknown policy", "orphan", nil, true, 0, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientSet := newFakeClientSet([]string{CRDAPIVersionV1}, NewCustomResourceDefinition())
+			dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), tt.instances...)
+			terminatingCRD(t, clientSet, dynamicClient)
+
+			err := DeleteCustomResourceDefinition(context.Background(), clientSet, dynamicClient, tt.policy)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DeleteCustomResourceDefinition() error = %v, want error %t", err, tt.wantErr)
+			}
+			crdDeletes := 0
+			for _, action := range clientSet.Actions() {
+				if action.Matches("delete", "customresourcedefinitions") {
+					crdDeletes++
+				}
+			}
+			wantCRDDeletes := 1
+			if tt.wantErr {
+				wantCRDDeletes = 0
+			}
+			if crdDeletes != wantCRDDeletes {
+				t.Errorf("got %d deletions of the CRD, want %d", crdDeletes, wantCRDDeletes)
+			}
+
+			list, err := dynamicClient.Resource(SchemeGroupVersion.WithResource(Plural)).List(context.Background(), metav1.ListOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(list.Items) != tt.wantInstances {
+				t.Errorf("got %d instances left, want %d", len(list.Items), tt.wantInstances)
+			}
+			for _, item := range list.Items {
+				if tt.policy == DeletionPolicyForce && len(item.GetFinalizers()) > 0 {
+					t.Errorf("instance %s has finalizers %v, want them stripped", item.GetName(), item.GetFinalizers())
+				}
+			}
+			deletes, patches := 0, 0
+			for _, action := range dynamicClient.Actions() {
+				switch {
+				case action.Matches("delete", Plural):
+					deletes++
+				case action.Matches("patch", Plural):
+					patches++
+				}
+			}
+			if deletes != tt.wantDeletes || patches != tt.wantPatches {
+				t.Errorf("got %d deletions and %d patches of instances, want %d and %d", deletes, patches, tt.wantDeletes, tt.wantPatches)
+			}
+		})
+	}
+}
+
+func TestDeleteCustomResourceDefinitionNotFound(t *testing.T) {
+	clientSet := newFakeClientSet([]string{CRDAPIVersionV1})
+	dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), newUnstructuredInstance("a"))
+
+	if err := DeleteCustomResourceDefinition(context.Background(), clientSet, dynamicClient, DeletionPolicyRefuse); err != nil {
+		t.Errorf("DeleteCustomResourceDefinition() error = %v, want nil when the CRD doesn't exist", err)
+	}
+	if n := len(dynamicClient.Actions()); n != 0 {
+		t.Errorf("got %d actions on the instances, want none", n)
+	}
+}
@@ -0,0 +1,254 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apiextensionsclientset "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/util/retry"
+)
+
+// CRDDiff describes one field which differs between the CRD in the cluster and the desired CRD.
+type CRDDiff struct {
+	// Path is the JSON path of the field, e.g. spec.versions[0].schema.openAPIV3Schema.type.
+	Path string
+	// Live is the value in the cluster. It is nil if the field is absent.
+	Live interface{}
+	// Desired is the value to apply. It is nil if the field is to be removed.
+	Desired interface{}
+}
+
+func (d CRDDiff) String() string {
+	return fmt.Sprintf("%s: %v -> %v", d.Path, d.Live, d.Desired)
+}
+
+// managedCRDSpec holds the CRD spec fields owned by this package. Fields owned by the API server are
+// left out so they never show up as a difference.
+type managedCRDSpec struct {
+	Group                 string                                            `json:"group"`
+	Names                 apiextensionsv1.CustomResourceDefinitionNames     `json:"names"`
+	Scope                 apiextensionsv1.ResourceScope                     `json:"scope"`
+	Versions              []apiextensionsv1.CustomResourceDefinitionVersion `json:"versions"`
+	Conversion            *apiextensionsv1.CustomResourceConversion         `json:"conversion"`
+	PreserveUnknownFields bool                                              `json:"preserveUnknownFields"`
+}
+
+// EnsureCustomResourceDefinition makes CRD Jinghzhu in the cluster match NewCustomResourceDefinition.
+// It creates the CRD if it doesn't exist. Otherwise, it compares the desired CRD with the live one and
+// updates the live one if they differ, retrying on conflict with the latest resourceVersion. A version
+// listed in status.storedVersions of the live CRD is never removed, see keepStoredVersions.
+// It returns the CRD in the cluster and the differences which were applied. It waits for the CRD to be
+// established until ctx is done, see CreateCustomResourceDefinition.
+func EnsureCustomResourceDefinition(ctx context.Context, clientSet apiextensionsclientset.Interface) (*apiextensionsv1.CustomResourceDefinition, []CRDDiff, error) {
+	crdClient, err := newCRDClient(clientSet)
+	if err != nil {
+		return nil, nil, err
+	}
+	desired := NewCustomResourceDefinition()
+
+	var (
+		result *apiextensionsv1.CustomResourceDefinition
+		diffs  []CRDDiff
+	)
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		live, err := crdClient.get(ctx, CRDName)
+		if apierrors.IsNotFound(err) {
+			result, diffs = nil, nil
+
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		want := keepStoredVersions(live, desired)
+		diffs, err = DiffCustomResourceDefinition(live, want)
+		if err != nil {
+			return err
+		}
+		if len(diffs) == 0 {
+			result = live
+
+			return nil
+		}
+		updated := live.DeepCopy()
+		updated.Spec.Group = want.Spec.Group
+		updated.Spec.Names = want.Spec.Names
+		updated.Spec.Scope = want.Spec.Scope
+		updated.Spec.Versions = want.Spec.Versions
+		updated.Spec.Conversion = want.Spec.Conversion
+		updated.Spec.PreserveUnknownFields = want.Spec.PreserveUnknownFields
+		result, err = crdClient.update(ctx, updated)
+
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if result == nil {
+		// CRD doesn't exist yet.
+		result, err = CreateCustomResourceDefinition(ctx, clientSet)
+
+		return result, nil, err
+	}
+	if len(diffs) > 0 {
+		result, err = waitForEstablished(ctx, crdClient)
+	}
+
+	return result, diffs, err
+}
+
+// keepStoredVersions returns desired plus the versions of live which are listed in its
+// status.storedVersions but missing in desired, e.g. v2 after the conversion webhook is unset. Instances
+// may still be stored in them, so the API server refuses to remove them. They are kept as they are in
+// live, except that they are no longer the storage version. If desired has no conversion webhook, the
+// conversion of live is kept as well, because the instances stored in those versions still need it.
+func keepStoredVersions(live, desired *apiextensionsv1.CustomResourceDefinition) *apiextensionsv1.CustomResourceDefinition {
+	served := make(map[string]bool)
+	for _, version := range desired.Spec.Versions {
+		served[version.Name] = true
+	}
+	want := desired
+	for _, stored := range live.Status.StoredVersions {
+		if served[stored] {
+			continue
+		}
+		for _, version := range live.Spec.Versions {
+			if version.Name != stored {
+				continue
+			}
+			if want == desired {
+				want = desired.DeepCopy()
+			}
+			fmt.Printf("Keep version %s of CRD Jinghzhu, which is in status.storedVersions\n", stored)
+			kept := version.DeepCopy()
+			kept.Storage = false
+			want.Spec.Versions = append(want.Spec.Versions, *kept)
+			served[stored] = true
+		}
+	}
+	if want != desired && (desired.Spec.Conversion == nil || desired.Spec.Conversion.Strategy != apiextensionsv1.WebhookConverter) {
+		want.Spec.Conversion = live.Spec.Conversion.DeepCopy()
+	}
+
+	return want
+}
+
+// DiffCustomResourceDefinition returns the differences of the fields managed by this package between
+// the live CRD and the desired CRD. The result is sorted by path.
+// Both are compared with the defaults the API server fills in, so a field which is only set by the
+// server, e.g. names.listKind or the port of the conversion webhook, isn't a difference. The defaults
+// of apiextensions.k8s.io/v1beta1 are applied as well, since on a v1beta1 cluster the live CRD has been
+// defaulted, and converted, through that API.
+func DiffCustomResourceDefinition(live, desired *apiextensionsv1.CustomResourceDefinition) ([]CRDDiff, error) {
+	liveSpec, err := defaultedSpec(live)
+	if err != nil {
+		return nil, err
+	}
+	desiredSpec, err := defaultedSpec(desired)
+	if err != nil {
+		return nil, err
+	}
+	liveJSON, err := toJSONValue(liveSpec)
+	if err != nil {
+		return nil, err
+	}
+	desiredJSON, err := toJSONValue(desiredSpec)
+	if err != nil {
+		return nil, err
+	}
+	diffs := diffJSON("spec", liveJSON, desiredJSON, nil)
+	sort.Slice(diffs, func(i, j int) bool {
+		return diffs[i].Path < diffs[j].Path
+	})
+
+	return diffs, nil
+}
+
+// defaultedSpec returns the managed spec of crd after the defaulting of apiextensions.k8s.io/v1 and of
+// a round trip through v1beta1.
+func defaultedSpec(crd *apiextensionsv1.CustomResourceDefinition) (managedCRDSpec, error) {
+	defaulted := crd.DeepCopy()
+	apiextensionsScheme.Default(defaulted)
+	crdV1beta1, err := toV1beta1(defaulted)
+	if err != nil {
+		return managedCRDSpec{}, err
+	}
+	apiextensionsScheme.Default(crdV1beta1)
+	defaulted, err = fromV1beta1(crdV1beta1)
+	if err != nil {
+		return managedCRDSpec{}, err
+	}
+
+	return managedSpec(defaulted), nil
+}
+
+func managedSpec(crd *apiextensionsv1.CustomResourceDefinition) managedCRDSpec {
+	return managedCRDSpec{
+		Group:                 crd.Spec.Group,
+		Names:                 crd.Spec.Names,
+		Scope:                 crd.Spec.Scope,
+		Versions:              crd.Spec.Versions,
+		Conversion:            crd.Spec.Conversion,
+		PreserveUnknownFields: crd.Spec.PreserveUnknownFields,
+	}
+}
+
+// toJSONValue turns obj into the generic JSON representation, i.e. maps, slices and scalars.
+func toJSONValue(obj interface{}) (interface{}, error) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	var value interface{}
+	if err = json.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
+
+// diffJSON walks both generic JSON values and appends a CRDDiff for every leaf which differs.
+func diffJSON(path string, live, desired interface{}, diffs []CRDDiff) []CRDDiff {
+	switch d := desired.(type) {
+	case map[string]interface{}:
+		l, ok := live.(map[string]interface{})
+		if !ok {
+			return append(diffs, CRDDiff{Path: path, Live: live, Desired: desired})
+		}
+		keys := map[string]bool{}
+		for k := range l {
+			keys[k] = true
+		}
+		for k := range d {
+			keys[k] = true
+		}
+		for k := range keys {
+			diffs = diffJSON(path+"."+k, l[k], d[k], diffs)
+		}
+
+		return diffs
+	case []interface{}:
+		l, ok := live.([]interface{})
+		if !ok || len(l) != len(d) {
+			return append(diffs, CRDDiff{Path: path, Live: live, Desired: desired})
+		}
+		for i := range d {
+			diffs = diffJSON(fmt.Sprintf("%s[%d]", path, i), l[i], d[i], diffs)
+		}
+
+		return diffs
+	}
+
+	if !reflect.DeepEqual(live, desired) {
+		diffs = append(diffs, CRDDiff{Path: path, Live: live, Desired: desired})
+	}
+
+	return diffs
+}
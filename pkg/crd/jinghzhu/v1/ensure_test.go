@@ -0,0 +1,132 @@
+package v1
+
+import (
+	"reflect"
+	"testing"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+// newWebhookCRD returns CRD Jinghzhu as created with the conversion webhook configured, after the
+// instances have been stored in both versions.
+func newWebhookCRD() *apiextensionsv1.CustomResourceDefinition {
+	crd := NewCustomResourceDefinition()
+	crd.Spec.Versions[0].Storage = false
+	crd.Spec.Versions = append(crd.Spec.Versions, newV2Version())
+	crd.Spec.Conversion = newWebhookConversion("crd/jinghzhu-conversion", "")
+	crd.Status.StoredVersions = []string{"v1", "v2"}
+
+	return crd
+}
+
+// throughV1beta1 returns crd as a v1beta1 cluster returns it.
+func throughV1beta1(t *testing.T, crd *apiextensionsv1.CustomResourceDefinition) *apiextensionsv1.CustomResourceDefinition {
+	crdV1beta1, err := toV1beta1(crd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The conversion hoists what all versions share to the spec, and the server sets the deprecated version.
+	crdV1beta1.Spec.Version = crdV1beta1.Spec.Versions[0].Name
+	live, err := fromV1beta1(crdV1beta1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return live
+}
+
+func TestDiffCustomResourceDefinition(t *testing.T) {
+	undefaulted := NewCustomResourceDefinition()
+	undefaulted.Spec.Names.Singular = ""
+	undefaulted.Spec.Names.ListKind = ""
+	undefaulted.Spec.Conversion = nil
+	webhook := newWebhookCRD()
+	webhook.Spec.Conversion.Webhook.ClientConfig.Service.Port = nil
+	priority := NewCustomResourceDefinition()
+	priority.Spec.Versions[0].AdditionalPrinterColumns[3].Priority = 0
+
+	tests := []struct {
+		name      string
+		live      *apiextensionsv1.CustomResourceDefinition
+		desired   *apiextensionsv1.CustomResourceDefinition
+		wantPaths []string
+	}{
+		{"same", NewCustomResourceDefinition(), NewCustomResourceDefinition(), nil},
+		{"defaulted by the server", NewCustomResourceDefinition(), undefaulted, nil},
+		{"through v1beta1", throughV1beta1(t, NewCustomResourceDefinition()), NewCustomResourceDefinition(), nil},
+		{"webhook port defaulted", newWebhookCRD(), webhook, nil},
+		{"webhook through v1beta1", throughV1beta1(t, newWebhookCRD()), newWebhookCRD(), nil},
+		{"column changed", priority, NewCustomResourceDefinition(), []string{"spec.versions[0].additionalPrinterColumns[3].priority"}},
+		{"version removed", newWebhookCRD(), NewCustomResourceDefinition(), []string{"spec.conversion.strategy", "spec.conversion.webhook", "spec.versions"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diffs, err := DiffCustomResourceDefinition(tt.live, tt.desired)
+			if err != nil {
+				t.Fatalf("DiffCustomResourceDefinition() error = %v", err)
+			}
+			var paths []string
+			for _, diff := range diffs {
+				paths = append(paths, diff.Path)
+			}
+			if !reflect.DeepEqual(paths, tt.wantPaths) {
+				t.Errorf("got %v, want %v", diffs, tt.wantPaths)
+			}
+		})
+	}
+}
+
+func TestKeepStoredVersions(t *testing.T) {
+	storedInV1 := newWebhookCRD()
+	storedInV1.Status.StoredVersions = []string{"v1"}
+	desiredWebhook := newWebhookCRD()
+	desiredWebhook.Status.StoredVersions = nil
+
+	tests := []struct {
+		name        string
+		live        *apiextensionsv1.CustomResourceDefinition
+		desired     *apiextensionsv1.CustomResourceDefinition
+		wantStorage map[string]bool
+		wantWebhook bool
+		// wantPaths are the differences Ensure applies.
+		wantPaths []string
+	}{
+		{"only v1", NewCustomResourceDefinition(), NewCustomResourceDefinition(), map[string]bool{"v1": true}, false, nil},
+		{"webhook unset", newWebhookCRD(), NewCustomResourceDefinition(), map[string]bool{"v1": true, "v2": false}, true,
+			[]string{"spec.versions[0].storage", "spec.versions[1].storage"}},
+		{"nothing stored in v2", storedInV1, NewCustomResourceDefinition(), map[string]bool{"v1": true}, false,
+			[]string{"spec.conversion.strategy", "spec.conversion.webhook", "spec.versions"}},
+		{"webhook kept", newWebhookCRD(), desiredWebhook, map[string]bool{"v1": false, "v2": true}, true, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			desired := tt.desired.DeepCopy()
+
+			got := keepStoredVersions(tt.live, desired)
+			if !reflect.DeepEqual(desired, tt.desired) {
+				t.Errorf("desired was changed to %+v", desired.Spec)
+			}
+			storage := make(map[string]bool)
+			for _, version := range got.Spec.Versions {
+				storage[version.Name] = version.Storage
+			}
+			if !reflect.DeepEqual(storage, tt.wantStorage) {
+				t.Errorf("got versions with storage %v, want %v", storage, tt.wantStorage)
+			}
+			if isWebhook := got.Spec.Conversion.Strategy == apiextensionsv1.WebhookConverter; isWebhook != tt.wantWebhook {
+				t.Errorf("conversion is %s, want webhook %t", got.Spec.Conversion.Strategy, tt.wantWebhook)
+			}
+			diffs, err := DiffCustomResourceDefinition(tt.live, got)
+			if err != nil {
+				t.Fatalf("DiffCustomResourceDefinition() error = %v", err)
+			}
+			var paths []string
+			for _, diff := range diffs {
+				paths = append(paths, diff.Path)
+			}
+			if !reflect.DeepEqual(paths, tt.wantPaths) {
+				t.Errorf("got %v, want %v", diffs, tt.wantPaths)
+			}
+		})
+	}
+}
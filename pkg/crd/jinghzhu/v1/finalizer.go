@@ -0,0 +1,42 @@
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ContainsFinalizer tells whether the object has the finalizer.
+func ContainsFinalizer(obj metav1.Object, finalizer string) bool {
+	for _, f := range obj.GetFinalizers() {
+		if f == finalizer {
+			return true
+		}
+	}
+
+	return false
+}
+
+// AddFinalizer adds the finalizer to the object. It returns false if the object already has it.
+func AddFinalizer(obj metav1.Object, finalizer string) bool {
+	if ContainsFinalizer(obj, finalizer) {
+		return false
+	}
+	obj.SetFinalizers(append(obj.GetFinalizers(), finalizer))
+
+	return true
+}
+
+// RemoveFinalizer removes the finalizer from the object. It returns false if the object doesn't have it.
+func RemoveFinalizer(obj metav1.Object, finalizer string) bool {
+	finalizers := make([]string, 0, len(obj.GetFinalizers()))
+	for _, f := range obj.GetFinalizers() {
+		if f != finalizer {
+			finalizers = append(finalizers, f)
+		}
+	}
+	if len(finalizers) == len(obj.GetFinalizers()) {
+		return false
+	}
+	obj.SetFinalizers(finalizers)
+
+	return true
+}
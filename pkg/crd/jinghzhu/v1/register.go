@@ -21,6 +21,8 @@ const (
 	CRDName string = Plural + "." + crdjinghzhu.GroupName
 	// ShortName is the short alias for the CRD.
 	ShortName string = "jh"
+	// FinalizerPodCleanup blocks the deletion of a Jinghzhu until the controller has deleted its Pods.
+	FinalizerPodCleanup string = crdjinghzhu.GroupName + "/pod-cleanup"
 )
 
 var (
@@ -0,0 +1,36 @@
+package v1
+
+import (
+	"github.com/jinghzhu/KubernetesCRD/pkg/types"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// MaxStateHistory is how many state transitions Status.History keeps.
+	MaxStateHistory int = 10
+)
+
+// SetState moves the status to state with the given message. It fails with
+// *types.InvalidTransitionError if types.Transition doesn't allow the move, and leaves the status
+// alone. If the state changes, the transition is appended to History and the oldest ones are dropped
+// beyond MaxStateHistory.
+func SetState(status *JinghzhuStatus, state types.State, message string) error {
+	if err := types.Transition(status.State, state); err != nil {
+		return err
+	}
+	if status.State != state {
+		status.History = append(status.History, StateTransition{
+			From:    status.State,
+			To:      state,
+			Time:    metav1.Now(),
+			Message: message,
+		})
+		if len(status.History) > MaxStateHistory {
+			status.History = append([]StateTransition(nil), status.History[len(status.History)-MaxStateHistory:]...)
+		}
+	}
+	status.State = state
+	status.Message = message
+
+	return nil
+}
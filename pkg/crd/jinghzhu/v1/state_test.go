@@ -0,0 +1,60 @@
+package v1
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/jinghzhu/KubernetesCRD/pkg/types"
+)
+
+func TestSetState(t *testing.T) {
+	status := &JinghzhuStatus{}
+	if err := SetState(status, types.StatePending, "created"); err != nil {
+		t.Fatalf("SetState() error = %v", err)
+	}
+	if status.State != types.StatePending || status.Message != "created" || len(status.History) != 1 {
+		t.Fatalf("status = %+v, want Pending with one transition", status)
+	}
+	if h := status.History[0]; h.From != "" || h.To != types.StatePending || h.Message != "created" || h.Time.IsZero() {
+		t.Errorf("transition = %+v", h)
+	}
+
+	// Staying in the same state only updates the message.
+	if err := SetState(status, types.StatePending, "1/2 Pods running"); err != nil {
+		t.Fatalf("SetState() error = %v", err)
+	}
+	if status.Message != "1/2 Pods running" || len(status.History) != 1 {
+		t.Errorf("status = %+v, want the new message and no new transition", status)
+	}
+
+	// An invalid move leaves the status alone.
+	if err := SetState(status, types.StateSucceeded, "done"); err != nil {
+		t.Fatalf("SetState() error = %v", err)
+	}
+	err := SetState(status, types.StateRunning, "restarted")
+	if _, ok := err.(*types.InvalidTransitionError); !ok {
+		t.Fatalf("SetState() error = %v, want *types.InvalidTransitionError", err)
+	}
+	if status.State != types.StateSucceeded || status.Message != "done" || len(status.History) != 2 {
+		t.Errorf("status = %+v, want it unchanged", status)
+	}
+}
+
+func TestSetStateHistoryLimit(t *testing.T) {
+	status := &JinghzhuStatus{}
+	states := []types.State{types.StatePending, types.StateRunning}
+	for i := 0; i < MaxStateHistory+5; i++ {
+		if err := SetState(status, states[i%2], fmt.Sprintf("move %d", i)); err != nil {
+			t.Fatalf("SetState() error = %v", err)
+		}
+	}
+	if len(status.History) != MaxStateHistory {
+		t.Fatalf("got %d transitions, want %d", len(status.History), MaxStateHistory)
+	}
+	if first := status.History[0].Message; first != "move 5" {
+		t.Errorf("oldest transition is %q, want move 5", first)
+	}
+	if last := status.History[MaxStateHistory-1].Message; last != fmt.Sprintf("move %d", MaxStateHistory+4) {
+		t.Errorf("latest transition is %q", last)
+	}
+}
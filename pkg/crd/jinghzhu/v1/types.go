@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/jinghzhu/KubernetesCRD/pkg/types"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -11,7 +12,8 @@ import (
 // ./k8s.io/code-generator/generate-groups.sh all github.com/jinghzhu/KubernetesCRD/pkg/crd/jinghzhu/v1/apis github.com/jinghzhu/KubernetesCRD/pkg/crd "jinghzhu:v1"
 // For more details of code-generator, please visit https://github.com/kubernetes/code-generator
 // +genclient
-// +genclient:noStatus
+// +genclient:method=GetScale,verb=get,subresource=scale,result=k8s.io/api/autoscaling/v1.Scale
+// +genclient:method=UpdateScale,verb=update,subresource=scale,input=k8s.io/api/autoscaling/v1.Scale,result=k8s.io/api/autoscaling/v1.Scale
 // +k8s:deepcopy-gen=true
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 // +resource:path=jinghzhu
@@ -26,20 +28,73 @@ type Jinghzhu struct {
 }
 
 // JinghzhuSpec is a desired state description of Jinghzhu.
+// The CRD validation schema is generated from this struct, see package schema for the openapi tag.
 // +k8s:deepcopy-gen=true
 type JinghzhuSpec struct {
 	// Desired is the desired Pod number.
-	Desired int `json:"desired"`
+	Desired int `json:"desired" openapi:"required,minimum=0"`
 	// Current is the number of Pod currently running.
-	Current int `json:"current"`
+	// Deprecated: it is observed state, use Status.Replicas. The controller doesn't write it. It is only
+	// filled from the status when the instance is stored as v2 and read as v1.
+	Current int `json:"current" openapi:"minimum=0"`
 	// PodList is the name list of current Pods.
+	// Deprecated: it is observed state, use Status.PodNames. It is filled like Current.
 	PodList []string `json:"podList"`
 }
 
 // JinghzhuStatus describes the lifecycle status of Jinghzhu.
+// +k8s:deepcopy-gen=true
 type JinghzhuStatus struct {
-	State   string `json:"state"`
-	Message string `json:"message"`
+	// State is the lifecycle state. Change it with SetState, which checks the transition.
+	State   types.State `json:"state" openapi:"enum=|Pending|Running|Succeeded|Failed|Terminating"`
+	Message string      `json:"message"`
+	// Replicas is the number of running Pods observed by the controller. It backs the status replicas of
+	// the scale subresource.
+	Replicas int `json:"replicas,omitempty" openapi:"minimum=0"`
+	// CurrentReplicas is the number of Pods which are neither terminated nor being deleted.
+	CurrentReplicas int `json:"currentReplicas,omitempty" openapi:"minimum=0"`
+	// PodNames is the name list of current Pods.
+	PodNames []string `json:"podNames,omitempty"`
+	// ObservedGeneration is the generation of the spec the status was computed for.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty" openapi:"minimum=0"`
+	// Selector is the serialized label selector of the Pods. It backs the label selector of the scale
+	// subresource, which HPA needs.
+	Selector string `json:"selector,omitempty"`
+	// Conditions are the latest observations of the state of Jinghzhu, e.g. ConditionAvailable.
+	Conditions []JinghzhuCondition `json:"conditions,omitempty"`
+	// History is the latest transitions of State, oldest first. It keeps at most MaxStateHistory of them.
+	History []StateTransition `json:"history,omitempty"`
+}
+
+// JinghzhuCondition is one aspect of the state of Jinghzhu. It has the same shape as the Condition of
+// newer apimachinery releases, so kubectl wait --for=condition=<type> works with it.
+// +k8s:deepcopy-gen=true
+type JinghzhuCondition struct {
+	// Type of the condition in CamelCase.
+	Type string `json:"type" openapi:"required,maxLength=316"`
+	// Status of the condition, one of True, False or Unknown.
+	Status metav1.ConditionStatus `json:"status" openapi:"required,enum=True|False|Unknown"`
+	// ObservedGeneration is the generation of the spec the condition was set for.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty" openapi:"minimum=0"`
+	// LastTransitionTime is the last time the status of the condition changed.
+	LastTransitionTime metav1.Time `json:"lastTransitionTime" openapi:"required"`
+	// Reason is a programmatic identifier of the last transition in CamelCase.
+	Reason string `json:"reason" openapi:"required,maxLength=1024"`
+	// Message is a human readable description of the last transition.
+	Message string `json:"message" openapi:"maxLength=32768"`
+}
+
+// StateTransition records one change of the state of Jinghzhu.
+// +k8s:deepcopy-gen=true
+type StateTransition struct {
+	// From is the state before the transition. It is empty for the first one.
+	From types.State `json:"from,omitempty"`
+	// To is the state after the transition.
+	To types.State `json:"to" openapi:"required"`
+	// Time is when the transition happened.
+	Time metav1.Time `json:"time" openapi:"required"`
+	// Message describes why the transition happened.
+	Message string `json:"message,omitempty"`
 }
 
 // JinghzhuList is the list of Jinghzhus.
@@ -60,8 +115,8 @@ func (j *Jinghzhu) String() string {
 		j.GetName(),
 		j.GetResourceVersion(),
 		j.Spec.Desired,
-		j.Spec.Current,
-		strings.Join(j.Spec.PodList, ", "),
+		j.Status.Replicas,
+		strings.Join(j.Status.PodNames, ", "),
 		j.Status.State,
 		j.Status.Message,
 	)
@@ -0,0 +1,101 @@
+package v1
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+	watchtools "k8s.io/client-go/tools/watch"
+)
+
+var (
+	// establishTimeout bounds the wait for CRD Jinghzhu to be established when the context has no deadline.
+	establishTimeout time.Duration = time.Minute
+)
+
+// EstablishFailure tells which condition stopped CRD Jinghzhu from becoming established.
+type EstablishFailure string
+
+const (
+	// EstablishFailureNamesNotAccepted means the NamesAccepted condition is False, e.g. the names conflict
+	// with another CRD.
+	EstablishFailureNamesNotAccepted EstablishFailure = "NamesNotAccepted"
+	// EstablishFailureTimeout means the context was cancelled or hit its deadline, or the wait took a minute
+	// without one, before the CRD was established.
+	EstablishFailureTimeout EstablishFailure = "Timeout"
+	// EstablishFailureDeleted means the CRD was deleted while waiting.
+	EstablishFailureDeleted EstablishFailure = "Deleted"
+)
+
+// EstablishError is returned when CRD Jinghzhu doesn't become established.
+type EstablishError struct {
+	// Name is the CRD name.
+	Name string
+	// Failure tells which condition failed.
+	Failure EstablishFailure
+	// Reason and Message come from the failed CRD condition, if any.
+	Reason  string
+	Message string
+}
+
+func (e *EstablishError) Error() string {
+	switch e.Failure {
+	case EstablishFailureNamesNotAccepted:
+		return fmt.Sprintf("CRD %s names are not accepted: %s: %s", e.Name, e.Reason, e.Message)
+	case EstablishFailureDeleted:
+		return fmt.Sprintf("CRD %s was deleted while waiting for it to be established", e.Name)
+	}
+
+	return fmt.Sprintf("timed out waiting for CRD %s to be established", e.Name)
+}
+
+// waitForEstablished watches CRD Jinghzhu until it is established and returns it. It stops when ctx is
+// done, or after establishTimeout if ctx has no deadline. Any failure is reported as *EstablishError.
+func waitForEstablished(ctx context.Context, crdClient *crdClient) (*apiextensionsv1.CustomResourceDefinition, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, establishTimeout)
+		defer cancel()
+	}
+	var crd *apiextensionsv1.CustomResourceDefinition
+	_, err := watchtools.UntilWithSync(ctx, crdClient.listWatch(ctx, CRDName), crdClient.objectType(), nil, func(event watch.Event) (bool, error) {
+		if event.Type == watch.Deleted {
+			return false, &EstablishError{Name: CRDName, Failure: EstablishFailureDeleted}
+		}
+		var err error
+		crd, err = toV1(event.Object)
+		if err != nil {
+			return false, err
+		}
+		for _, cond := range crd.Status.Conditions {
+			switch cond.Type {
+			case apiextensionsv1.Established:
+				if cond.Status == apiextensionsv1.ConditionTrue {
+					return true, nil
+				}
+			case apiextensionsv1.NamesAccepted:
+				if cond.Status == apiextensionsv1.ConditionFalse {
+					return false, &EstablishError{
+						Name:    CRDName,
+						Failure: EstablishFailureNamesNotAccepted,
+						Reason:  cond.Reason,
+						Message: cond.Message,
+					}
+				}
+			}
+		}
+
+		return false, nil
+	})
+	if err == wait.ErrWaitTimeout {
+		return nil, &EstablishError{Name: CRDName, Failure: EstablishFailureTimeout}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return crd, nil
+}
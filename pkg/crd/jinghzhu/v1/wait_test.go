@@ -0,0 +1,57 @@
+package v1
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apiextensionsfake "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/fake"
+)
+
+// withConditions returns CRD Jinghzhu with the given conditions.
+func withConditions(conditions ...apiextensionsv1.CustomResourceDefinitionCondition) *apiextensionsv1.CustomResourceDefinition {
+	crd := NewCustomResourceDefinition()
+	crd.Status.Conditions = conditions
+
+	return crd
+}
+
+func TestWaitForEstablished(t *testing.T) {
+	defer func(timeout time.Duration) { establishTimeout = timeout }(establishTimeout)
+	establishTimeout = 100 * time.Millisecond
+
+	tests := []struct {
+		name        string
+		crd         *apiextensionsv1.CustomResourceDefinition
+		wantFailure EstablishFailure
+	}{
+		{"established", withConditions(
+			apiextensionsv1.CustomResourceDefinitionCondition{Type: apiextensionsv1.NamesAccepted, Status: apiextensionsv1.ConditionTrue},
+			apiextensionsv1.CustomResourceDefinitionCondition{Type: apiextensionsv1.Established, Status: apiextensionsv1.ConditionTrue},
+		), ""},
+		{"names not accepted", withConditions(
+			apiextensionsv1.CustomResourceDefinitionCondition{Type: apiextensionsv1.NamesAccepted, Status: apiextensionsv1.ConditionFalse, Reason: "ListKindConflict"},
+		), EstablishFailureNamesNotAccepted},
+		// ctx has no deadline, so only the default timeout ends the wait.
+		{"never established", withConditions(), EstablishFailureTimeout},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			crdClient := &crdClient{clientSet: apiextensionsfake.NewSimpleClientset(tt.crd), version: CRDAPIVersionV1}
+
+			crd, err := waitForEstablished(context.Background(), crdClient)
+			if tt.wantFailure == "" {
+				if err != nil || crd == nil {
+					t.Errorf("waitForEstablished() = %v, %v, want the CRD", crd, err)
+				}
+
+				return
+			}
+			establishErr, ok := err.(*EstablishError)
+			if !ok || establishErr.Failure != tt.wantFailure {
+				t.Errorf("waitForEstablished() error = %v, want *EstablishError of %s", err, tt.wantFailure)
+			}
+		})
+	}
+}
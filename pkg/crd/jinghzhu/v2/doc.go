@@ -0,0 +1,8 @@
+// +k8s:deepcopy-gen=package,register
+// +k8s:defaulter-gen=TypeMeta
+// +k8s:openapi-gen=true
+
+// Package v2 is the v2 version of the API. Unlike v1, the observed state lives in the status only, so
+// the controller never writes the spec. See package conversion for the conversion from and to v1.
+// +groupName=jinghzhu.io
+package v2
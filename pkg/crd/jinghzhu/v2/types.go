@@ -0,0 +1,92 @@
+package v2
+
+import (
+	"github.com/jinghzhu/KubernetesCRD/pkg/types"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Jinghzhu is the CRD.
+// +k8s:deepcopy-gen=true
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +resource:path=jinghzhu
+type Jinghzhu struct {
+	metav1.TypeMeta `json:",inline"`
+	// Standard object's metadata.
+	metav1.ObjectMeta `json:"metadata"`
+	// Specification of the desired behavior of Jinghzhu.
+	Spec JinghzhuSpec `json:"spec"`
+	// Observed status of Jinghzhu.
+	Status JinghzhuStatus `json:"status,omitempty"`
+}
+
+// JinghzhuSpec is a desired state description of Jinghzhu.
+// +k8s:deepcopy-gen=true
+type JinghzhuSpec struct {
+	// Desired is the desired Pod number.
+	Desired int `json:"desired" openapi:"required,minimum=0"`
+}
+
+// JinghzhuStatus is the state of Jinghzhu observed by the controller.
+// +k8s:deepcopy-gen=true
+type JinghzhuStatus struct {
+	State   types.State `json:"state,omitempty" openapi:"enum=|Pending|Running|Succeeded|Failed|Terminating"`
+	Message string      `json:"message,omitempty"`
+	// CurrentReplicas is the number of Pods which are neither terminated nor being deleted.
+	CurrentReplicas int `json:"currentReplicas,omitempty" openapi:"minimum=0"`
+	// ReadyReplicas is the number of running Pods. It backs the status replicas of the scale subresource.
+	ReadyReplicas int `json:"readyReplicas,omitempty" openapi:"minimum=0"`
+	// PodNames is the name list of current Pods.
+	PodNames []string `json:"podNames,omitempty"`
+	// ObservedGeneration is the generation of the spec the status was computed for.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty" openapi:"minimum=0"`
+	// Selector is the serialized label selector of the Pods. It backs the label selector of the scale
+	// subresource, which HPA needs.
+	Selector string `json:"selector,omitempty"`
+	// Conditions are the latest observations of the state of Jinghzhu.
+	Conditions []JinghzhuCondition `json:"conditions,omitempty"`
+	// History is the latest transitions of State, oldest first.
+	History []StateTransition `json:"history,omitempty"`
+}
+
+// JinghzhuCondition is one aspect of the state of Jinghzhu. It has the same shape as the Condition of
+// newer apimachinery releases.
+// +k8s:deepcopy-gen=true
+type JinghzhuCondition struct {
+	// Type of the condition in CamelCase.
+	Type string `json:"type" openapi:"required,maxLength=316"`
+	// Status of the condition, one of True, False or Unknown.
+	Status metav1.ConditionStatus `json:"status" openapi:"required,enum=True|False|Unknown"`
+	// ObservedGeneration is the generation of the spec the condition was set for.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty" openapi:"minimum=0"`
+	// LastTransitionTime is the last time the status of the condition changed.
+	LastTransitionTime metav1.Time `json:"lastTransitionTime" openapi:"required"`
+	// Reason is a programmatic identifier of the last transition in CamelCase.
+	Reason string `json:"reason" openapi:"required,maxLength=1024"`
+	// Message is a human readable description of the last transition.
+	Message string `json:"message" openapi:"maxLength=32768"`
+}
+
+// StateTransition records one change of the state of Jinghzhu.
+// +k8s:deepcopy-gen=true
+type StateTransition struct {
+	// From is the state before the transition. It is empty for the first one.
+	From types.State `json:"from,omitempty"`
+	// To is the state after the transition.
+	To types.State `json:"to" openapi:"required"`
+	// Time is when the transition happened.
+	Time metav1.Time `json:"time" openapi:"required"`
+	// Message describes why the transition happened.
+	Message string `json:"message,omitempty"`
+}
+
+// JinghzhuList is the list of Jinghzhus.
+// +k8s:deepcopy-gen=true
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +resource:path=jinghzhu
+type JinghzhuList struct {
+	metav1.TypeMeta `json:",inline"`
+	// Standard list metadata.
+	metav1.ListMeta `json:"metadata"`
+	// List of Jinghzhus.
+	Items []Jinghzhu `json:"items"`
+}
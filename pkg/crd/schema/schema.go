@@ -0,0 +1,233 @@
+// Package schema generates the OpenAPI v3 validation schema of a CRD from its Go types, so the schema
+// registered in Kubernetes never drifts from types.go.
+//
+// The generator walks the type via reflection. Property names come from the json tag and fields tagged
+// with json:",inline" are flattened into their parent. Extra validation is declared with the openapi tag,
+// which takes a comma separated list of markers:
+//
+//	Desired int `json:"desired" openapi:"required,minimum=0"`
+//
+// Supported markers are required, minimum=<n>, maximum=<n>, minLength=<n>, maxLength=<n>,
+// pattern=<regexp>, format=<format> and enum=<a>|<b>|<c>.
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// TagName is the struct tag holding the validation markers.
+	TagName string = "openapi"
+)
+
+var (
+	typeMetaType   = reflect.TypeOf(metav1.TypeMeta{})
+	objectMetaType = reflect.TypeOf(metav1.ObjectMeta{})
+	listMetaType   = reflect.TypeOf(metav1.ListMeta{})
+	timeType       = reflect.TypeOf(metav1.Time{})
+	durationType   = reflect.TypeOf(metav1.Duration{})
+)
+
+// Generate returns the structural OpenAPI v3 schema of obj, which is normally the zero value of the
+// top level CRD type, e.g. Jinghzhu{}. It panics if the type can't be described by a structural schema
+// or if an openapi tag is malformed, because both are programming errors in types.go.
+func Generate(obj interface{}) *apiextensionsv1.JSONSchemaProps {
+	props := generate(reflect.TypeOf(obj))
+
+	return &props
+}
+
+func generate(t reflect.Type) apiextensionsv1.JSONSchemaProps {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t {
+	case objectMetaType, listMetaType:
+		// The API server owns the schema of metadata.
+		return apiextensionsv1.JSONSchemaProps{Type: "object"}
+	case timeType:
+		return apiextensionsv1.JSONSchemaProps{Type: "string", Format: "date-time"}
+	case durationType:
+		return apiextensionsv1.JSONSchemaProps{Type: "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return apiextensionsv1.JSONSchemaProps{Type: "string"}
+	case reflect.Bool:
+		return apiextensionsv1.JSONSchemaProps{Type: "boolean"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16:
+		return apiextensionsv1.JSONSchemaProps{Type: "integer", Format: "int32"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return apiextensionsv1.JSONSchemaProps{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return apiextensionsv1.JSONSchemaProps{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return apiextensionsv1.JSONSchemaProps{Type: "number", Format: "double"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return apiextensionsv1.JSONSchemaProps{Type: "string", Format: "byte"}
+		}
+		items := generate(t.Elem())
+
+		return apiextensionsv1.JSONSchemaProps{
+			Type:  "array",
+			Items: &apiextensionsv1.JSONSchemaPropsOrArray{Schema: &items},
+		}
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			panic(fmt.Sprintf("schema: map key of %s must be string", t))
+		}
+		values := generate(t.Elem())
+
+		return apiextensionsv1.JSONSchemaProps{
+			Type:                 "object",
+			AdditionalProperties: &apiextensionsv1.JSONSchemaPropsOrBool{Allows: true, Schema: &values},
+		}
+	case reflect.Interface:
+		return apiextensionsv1.JSONSchemaProps{XPreserveUnknownFields: &[]bool{true}[0]}
+	case reflect.Struct:
+		props := apiextensionsv1.JSONSchemaProps{
+			Type:       "object",
+			Properties: map[string]apiextensionsv1.JSONSchemaProps{},
+		}
+		addFields(&props, t)
+
+		return props
+	}
+
+	panic(fmt.Sprintf("schema: unsupported type %s", t))
+}
+
+// addFields adds the exported fields of struct t into the properties of props.
+func addFields(props *apiextensionsv1.JSONSchemaProps, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		name, inline := JSONName(field)
+		if name == "-" {
+			continue
+		}
+		if inline {
+			if field.Type == typeMetaType {
+				props.Properties["apiVersion"] = apiextensionsv1.JSONSchemaProps{Type: "string"}
+				props.Properties["kind"] = apiextensionsv1.JSONSchemaProps{Type: "string"}
+
+				continue
+			}
+			addFields(props, field.Type)
+
+			continue
+		}
+
+		fieldProps := generate(field.Type)
+		if applyMarkers(&fieldProps, field) {
+			props.Required = append(props.Required, name)
+		}
+		props.Properties[name] = fieldProps
+	}
+}
+
+// JSONName returns the property name of the field, which is "-" if it is skipped, and whether it is
+// inlined into its parent. Everything which maps JSON paths to Go fields, e.g. the JSON Patch builder of
+// the client, uses it, so it agrees with the schema.
+func JSONName(field reflect.StructField) (string, bool) {
+	tag := field.Tag.Get("json")
+	name := strings.Split(tag, ",")[0]
+	if strings.Contains(tag, ",inline") || (field.Anonymous && name == "") {
+		return "", true
+	}
+	if name == "" {
+		name = field.Name
+	}
+
+	return name, false
+}
+
+// applyMarkers sets the validation declared in the openapi tag of field. It returns whether the field
+// is required.
+func applyMarkers(props *apiextensionsv1.JSONSchemaProps, field reflect.StructField) bool {
+	tag, ok := field.Tag.Lookup(TagName)
+	if !ok {
+		return false
+	}
+
+	required := false
+	for _, marker := range strings.Split(tag, ",") {
+		if marker == "" {
+			continue
+		}
+		key, value := marker, ""
+		if i := strings.Index(marker, "="); i >= 0 {
+			key, value = marker[:i], marker[i+1:]
+		}
+		switch key {
+		case "required":
+			required = true
+		case "minimum":
+			props.Minimum = mustParseFloat(field, key, value)
+		case "maximum":
+			props.Maximum = mustParseFloat(field, key, value)
+		case "minLength":
+			props.MinLength = mustParseInt(field, key, value)
+		case "maxLength":
+			props.MaxLength = mustParseInt(field, key, value)
+		case "pattern":
+			props.Pattern = value
+		case "format":
+			props.Format = value
+		case "enum":
+			for _, v := range strings.Split(value, "|") {
+				props.Enum = append(props.Enum, enumValue(field, props.Type, v))
+			}
+		default:
+			panic(fmt.Sprintf("schema: unknown marker %q on field %s", key, field.Name))
+		}
+	}
+
+	return required
+}
+
+func mustParseFloat(field reflect.StructField, key, value string) *float64 {
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		panic(fmt.Sprintf("schema: invalid %s %q on field %s: %v", key, value, field.Name, err))
+	}
+
+	return &f
+}
+
+func mustParseInt(field reflect.StructField, key, value string) *int64 {
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		panic(fmt.Sprintf("schema: invalid %s %q on field %s: %v", key, value, field.Name, err))
+	}
+
+	return &n
+}
+
+// enumValue encodes one enum value as JSON according to the schema type of the field.
+func enumValue(field reflect.StructField, schemaType, value string) apiextensionsv1.JSON {
+	var raw []byte
+	switch schemaType {
+	case "string":
+		raw, _ = json.Marshal(value)
+	default:
+		if !json.Valid([]byte(value)) {
+			panic(fmt.Sprintf("schema: invalid enum value %q on field %s", value, field.Name))
+		}
+		raw = []byte(value)
+	}
+
+	return apiextensionsv1.JSON{Raw: raw}
+}
@@ -0,0 +1,188 @@
+package schema
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type testObject struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              testSpec `json:"spec"`
+}
+
+// EmbeddedSpec is exported, because the fields of unexported embedded structs are skipped.
+type EmbeddedSpec struct {
+	Embedded string `json:"embedded"`
+}
+
+type testSpec struct {
+	EmbeddedSpec
+	Replicas int               `json:"replicas" openapi:"required,minimum=0,maximum=10"`
+	Name     string            `json:"name,omitempty" openapi:"minLength=1,maxLength=63,pattern=^[a-z]+$"`
+	Phase    string            `json:"phase" openapi:"enum=|A|B"`
+	Level    int32             `json:"level" openapi:"enum=1|2"`
+	Ratio    float64           `json:"ratio"`
+	Enabled  bool              `json:"enabled"`
+	Data     []byte            `json:"data"`
+	Items    []string          `json:"items"`
+	Labels   map[string]string `json:"labels"`
+	Since    metav1.Time       `json:"since"`
+	Timeout  metav1.Duration   `json:"timeout"`
+	Pointer  *int64            `json:"pointer"`
+	Any      interface{}       `json:"any"`
+	NoTag    string
+	Skipped  string `json:"-"`
+	private  string
+}
+
+func TestGenerate(t *testing.T) {
+	props := Generate(testObject{})
+
+	if props.Type != "object" {
+		t.Fatalf("type = %q, want object", props.Type)
+	}
+	for _, name := range []string{"apiVersion", "kind", "metadata", "spec"} {
+		if _, ok := props.Properties[name]; !ok {
+			t.Errorf("missing property %q", name)
+		}
+	}
+	if len(props.Properties) != 4 {
+		t.Errorf("got %d properties, want 4", len(props.Properties))
+	}
+	if metadata := props.Properties["metadata"]; !reflect.DeepEqual(metadata, apiextensionsv1.JSONSchemaProps{Type: "object"}) {
+		t.Errorf("metadata = %+v, want a plain object", metadata)
+	}
+
+	spec := props.Properties["spec"]
+	if !reflect.DeepEqual(spec.Required, []string{"replicas"}) {
+		t.Errorf("required = %v, want [replicas]", spec.Required)
+	}
+	for _, name := range []string{"Skipped", "-", "private"} {
+		if _, ok := spec.Properties[name]; ok {
+			t.Errorf("property %q should be skipped", name)
+		}
+	}
+
+	tests := []struct {
+		name string
+		want apiextensionsv1.JSONSchemaProps
+	}{
+		{"embedded", apiextensionsv1.JSONSchemaProps{Type: "string"}},
+		{"replicas", apiextensionsv1.JSONSchemaProps{Type: "integer", Format: "int64", Minimum: float64Ptr(0), Maximum: float64Ptr(10)}},
+		{"name", apiextensionsv1.JSONSchemaProps{Type: "string", MinLength: int64Ptr(1), MaxLength: int64Ptr(63), Pattern: "^[a-z]+$"}},
+		{"phase", apiextensionsv1.JSONSchemaProps{Type: "string", Enum: enum(`""`, `"A"`, `"B"`)}},
+		{"level", apiextensionsv1.JSONSchemaProps{Type: "integer", Format: "int32", Enum: enum(`1`, `2`)}},
+		{"ratio", apiextensionsv1.JSONSchemaProps{Type: "number", Format: "double"}},
+		{"enabled", apiextensionsv1.JSONSchemaProps{Type: "boolean"}},
+		{"data", apiextensionsv1.JSONSchemaProps{Type: "string", Format: "byte"}},
+		{"items", apiextensionsv1.JSONSchemaProps{
+			Type:  "array",
+			Items: &apiextensionsv1.JSONSchemaPropsOrArray{Schema: &apiextensionsv1.JSONSchemaProps{Type: "string"}},
+		}},
+		{"labels", apiextensionsv1.JSONSchemaProps{
+			Type:                 "object",
+			AdditionalProperties: &apiextensionsv1.JSONSchemaPropsOrBool{Allows: true, Schema: &apiextensionsv1.JSONSchemaProps{Type: "string"}},
+		}},
+		{"since", apiextensionsv1.JSONSchemaProps{Type: "string", Format: "date-time"}},
+		{"timeout", apiextensionsv1.JSONSchemaProps{Type: "string"}},
+		{"pointer", apiextensionsv1.JSONSchemaProps{Type: "integer", Format: "int64"}},
+		{"any", apiextensionsv1.JSONSchemaProps{XPreserveUnknownFields: boolPtr(true)}},
+		{"NoTag", apiextensionsv1.JSONSchemaProps{Type: "string"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := spec.Properties[tt.name]
+			if !ok {
+				t.Fatalf("missing property %q", tt.name)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				gotJSON, _ := json.Marshal(got)
+				wantJSON, _ := json.Marshal(tt.want)
+				t.Errorf("got %s, want %s", gotJSON, wantJSON)
+			}
+		})
+	}
+}
+
+func TestGeneratePanics(t *testing.T) {
+	tests := []struct {
+		name string
+		obj  interface{}
+	}{
+		{"unknown marker", struct {
+			A int `json:"a" openapi:"bogus"`
+		}{}},
+		{"invalid minimum", struct {
+			A int `json:"a" openapi:"minimum=x"`
+		}{}},
+		{"invalid enum value", struct {
+			A int `json:"a" openapi:"enum=x"`
+		}{}},
+		{"non-string map key", struct {
+			A map[int]string `json:"a"`
+		}{}},
+		{"unsupported type", struct {
+			A chan int `json:"a"`
+		}{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("Generate didn't panic")
+				}
+			}()
+			Generate(tt.obj)
+		})
+	}
+}
+
+func TestJSONName(t *testing.T) {
+	typ := reflect.TypeOf(testSpec{})
+	tests := []struct {
+		field      string
+		wantName   string
+		wantInline bool
+	}{
+		{"EmbeddedSpec", "", true},
+		{"Replicas", "replicas", false},
+		{"Name", "name", false},
+		{"NoTag", "NoTag", false},
+		{"Skipped", "-", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.field, func(t *testing.T) {
+			field, _ := typ.FieldByName(tt.field)
+			name, inline := JSONName(field)
+			if name != tt.wantName || inline != tt.wantInline {
+				t.Errorf("JSONName() = %q, %v, want %q, %v", name, inline, tt.wantName, tt.wantInline)
+			}
+		})
+	}
+}
+
+func enum(values ...string) []apiextensionsv1.JSON {
+	out := make([]apiextensionsv1.JSON, 0, len(values))
+	for _, v := range values {
+		out = append(out, apiextensionsv1.JSON{Raw: []byte(v)})
+	}
+
+	return out
+}
+
+func float64Ptr(f float64) *float64 {
+	return &f
+}
+
+func int64Ptr(n int64) *int64 {
+	return &n
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
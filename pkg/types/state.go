@@ -0,0 +1,63 @@
+package types
+
+import (
+	"fmt"
+)
+
+// transitions lists the states each state may move to. The empty state is the one of an instance
+// nobody has processed yet. Succeeded and Failed are final, only the deletion can follow them.
+var transitions = map[State][]State{
+	"":               {StatePending, StateRunning, StateSucceeded, StateFailed, StateTerminating},
+	StatePending:     {StateRunning, StateSucceeded, StateFailed, StateTerminating},
+	StateRunning:     {StatePending, StateSucceeded, StateFailed, StateTerminating},
+	StateSucceeded:   {StateTerminating},
+	StateFailed:      {StateTerminating},
+	StateTerminating: {},
+}
+
+// InvalidTransitionError is returned by Transition when a state can't move to another one.
+type InvalidTransitionError struct {
+	From State
+	To   State
+}
+
+func (e *InvalidTransitionError) Error() string {
+	if !e.From.IsValid() {
+		return fmt.Sprintf("unknown state %q", e.From)
+	}
+	if !e.To.IsValid() {
+		return fmt.Sprintf("unknown state %q", e.To)
+	}
+
+	return fmt.Sprintf("state %q can't move to %q", e.From, e.To)
+}
+
+// IsValid returns whether s is a known state. The empty state is valid.
+func (s State) IsValid() bool {
+	_, ok := transitions[s]
+
+	return ok
+}
+
+// IsFinal returns whether nothing but the deletion can follow s, i.e. s is Succeeded or Failed.
+func (s State) IsFinal() bool {
+	return s == StateSucceeded || s == StateFailed
+}
+
+// Transition checks that state from may move to state to. Staying in the same known state is always
+// allowed. Otherwise the error is *InvalidTransitionError.
+func Transition(from, to State) error {
+	if !from.IsValid() || !to.IsValid() {
+		return &InvalidTransitionError{From: from, To: to}
+	}
+	if from == to {
+		return nil
+	}
+	for _, next := range transitions[from] {
+		if next == to {
+			return nil
+		}
+	}
+
+	return &InvalidTransitionError{From: from, To: to}
+}
@@ -0,0 +1,95 @@
+package types
+
+import (
+	"testing"
+)
+
+func TestTransition(t *testing.T) {
+	tests := []struct {
+		from    State
+		to      State
+		wantErr bool
+	}{
+		{"", StatePending, false},
+		{"", StateTerminating, false},
+		{"", "", false},
+		{StatePending, StatePending, false},
+		{StatePending, StateRunning, false},
+		{StatePending, StateFailed, false},
+		{StateRunning, StatePending, false},
+		{StateRunning, StateSucceeded, false},
+		{StateRunning, StateTerminating, false},
+		{StateSucceeded, StateTerminating, false},
+		{StateFailed, StateTerminating, false},
+		{StateSucceeded, StateSucceeded, false},
+		{StatePending, "", true},
+		{StateSucceeded, StateRunning, true},
+		{StateFailed, StatePending, true},
+		{StateSucceeded, StateFailed, true},
+		{StateTerminating, StateRunning, true},
+		{StateTerminating, "", true},
+		{"Unknown", StatePending, true},
+		{StatePending, "Unknown", true},
+		{"Unknown", "Unknown", true},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.from)+"->"+string(tt.to), func(t *testing.T) {
+			err := Transition(tt.from, tt.to)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Transition() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil {
+				return
+			}
+			transitionErr, ok := err.(*InvalidTransitionError)
+			if !ok {
+				t.Fatalf("Transition() error is %T, want *InvalidTransitionError", err)
+			}
+			if transitionErr.From != tt.from || transitionErr.To != tt.to {
+				t.Errorf("error is about %q -> %q", transitionErr.From, transitionErr.To)
+			}
+		})
+	}
+}
+
+func TestInvalidTransitionError(t *testing.T) {
+	tests := []struct {
+		from State
+		to   State
+		want string
+	}{
+		{"Unknown", StatePending, `unknown state "Unknown"`},
+		{StatePending, "Unknown", `unknown state "Unknown"`},
+		{StateSucceeded, StateRunning, `state "Succeeded" can't move to "Running"`},
+	}
+	for _, tt := range tests {
+		err := &InvalidTransitionError{From: tt.from, To: tt.to}
+		if got := err.Error(); got != tt.want {
+			t.Errorf("Error() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestState(t *testing.T) {
+	tests := []struct {
+		state     State
+		wantValid bool
+		wantFinal bool
+	}{
+		{"", true, false},
+		{StatePending, true, false},
+		{StateRunning, true, false},
+		{StateSucceeded, true, true},
+		{StateFailed, true, true},
+		{StateTerminating, true, false},
+		{"Unknown", false, false},
+	}
+	for _, tt := range tests {
+		if got := tt.state.IsValid(); got != tt.wantValid {
+			t.Errorf("%q.IsValid() = %v, want %v", tt.state, got, tt.wantValid)
+		}
+		if got := tt.state.IsFinal(); got != tt.wantFinal {
+			t.Errorf("%q.IsFinal() = %v, want %v", tt.state, got, tt.wantFinal)
+		}
+	}
+}